@@ -0,0 +1,132 @@
+package gortree
+
+import (
+	"errors"
+	"math"
+)
+
+// Summary aggregates the values of a set of entries, as extracted by the function set with WithAggregate. Min and Max
+// are zero when Count is zero.
+type Summary struct {
+	Count    int
+	Sum      float64
+	Min, Max float64
+}
+
+// emptySummary is the identity of merge.
+var emptySummary = Summary{Min: math.Inf(1), Max: math.Inf(-1)}
+
+// merge returns the summary of the union of both sets of entries.
+func (s Summary) merge(other Summary) Summary {
+	return Summary{
+		Count: s.Count + other.Count,
+		Sum:   s.Sum + other.Sum,
+		Min:   math.Min(s.Min, other.Min),
+		Max:   math.Max(s.Max, other.Max),
+	}
+}
+
+// computeNodesSummary returns the summary of the entries of all nodes.
+func computeNodesSummary(nodes []*node) Summary {
+	s := emptySummary
+	for _, n := range nodes {
+		s = s.merge(n.Summary)
+	}
+	return s
+}
+
+// WithAggregate sets the function extracting the numeric value of each entry, whose sum, min and max are kept by every
+// node for its subtree and returned by Aggregate.
+func WithAggregate(value func(Spatial) float64) Option {
+	return func(t *RTree) error {
+		if value == nil {
+			return errors.New("aggregate value function is nil")
+		}
+		t.value = value
+		return nil
+	}
+}
+
+// summaryOf returns the summary of a single entry.
+func (t *RTree) summaryOf(data Spatial) Summary {
+	if t.value == nil {
+		return Summary{Count: 1}
+	}
+	v := t.value(data)
+	return Summary{Count: 1, Sum: v, Min: v, Max: v}
+}
+
+// Count returns the number of entries intersecting r, like len(Query(r)) without collecting them. Nodes fully
+// contained in r are counted from their stored subtree count instead of being descended.
+func (t *RTree) Count(r Rect) int {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.summarize(OpCount, r.Box()).Count
+}
+
+// Aggregate returns the count, sum, min and max of the values of the entries intersecting r, as extracted by the
+// function set with WithAggregate. Nodes fully contained in r contribute their stored subtree summary instead of being
+// descended.
+func (t *RTree) Aggregate(r Rect) (Summary, error) {
+
+	if t.value == nil {
+		return Summary{}, errors.New("aggregate: no value function, set one with WithAggregate")
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.summarize(OpAggregate, r.Box()), nil
+}
+
+// summarize returns the summary of the entries intersecting r, hiding the expired ones. The stored summary of a node
+// is used when r contains its bounding box and none of its entries has expired. Requires t.mu held.
+func (t *RTree) summarize(op QueryOp, r Box) Summary {
+
+	start := t.startQuery()
+	stats := QueryStats{Op: op}
+
+	s := emptySummary
+	now := t.now()
+
+	stack := NewStackFrom(t.root)
+
+	for !stack.Empty() {
+
+		cur, _ := stack.Pop()
+		stats.NodesVisited++
+
+		if !cur.BoundingBox.Intersects(r) {
+			continue
+		}
+
+		// The subtree is entirely in the query
+		if len(cur.Children) > 0 && r.Contains(cur.BoundingBox) && !expired(cur, now) {
+			s = s.merge(cur.Summary)
+			continue
+		}
+
+		if cur.IsLeaf {
+			stats.LeavesVisited++
+			stats.EntriesTested += len(cur.Children)
+			for _, e := range cur.Children {
+				if e.BoundingBox.Intersects(r) && !expired(e, now) {
+					s = s.merge(e.Summary)
+				}
+			}
+		} else {
+			stack.Push(cur.Children...)
+		}
+	}
+
+	if s.Count == 0 {
+		s = Summary{}
+	}
+
+	stats.Results = s.Count
+	t.observeQuery(stats, start)
+
+	return s
+}
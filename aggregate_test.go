@@ -0,0 +1,112 @@
+package gortree_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/lambertmata/gortree"
+)
+
+// longitude extracts the longitude of a location, as the aggregated value.
+func longitude(data gortree.Spatial) float64 {
+	return data.(*Location).Coordinates[0]
+}
+
+func TestRTree_CountAggregate(t *testing.T) {
+
+	rec := &recorder{}
+	rt, err := gortree.NewRTreeWithOptions(gortree.WithAggregate(longitude), gortree.WithMinMax(4, 16), gortree.WithObserver(rec))
+	if err != nil {
+		t.Fatalf("NewRTreeWithOptions: %v", err)
+	}
+
+	locations := randomLocations(2000, 9)
+	for _, l := range locations[:1000] {
+		rt.Insert(l)
+	}
+	rt.InsertBatch(toSpatial(locations[1000:]))
+	for _, l := range locations[:500] {
+		_ = rt.Delete(l)
+	}
+	remaining := locations[500:]
+
+	rnd := rand.New(rand.NewPCG(1, 2))
+
+	for i := 0; i < 50; i++ {
+
+		x, y := rnd.Float64()*360-180, rnd.Float64()*180-90
+		window := *gortree.NewRect(x, y, x+rnd.Float64()*120, y+rnd.Float64()*60)
+
+		expected := gortree.Summary{Min: math.Inf(1), Max: math.Inf(-1)}
+		for _, l := range remaining {
+			if bbox := l.BoundingBox(); bbox.Intersects(window) {
+				v := l.Coordinates[0]
+				expected.Count++
+				expected.Sum += v
+				expected.Min = math.Min(expected.Min, v)
+				expected.Max = math.Max(expected.Max, v)
+			}
+		}
+		if expected.Count == 0 {
+			expected = gortree.Summary{}
+		}
+
+		if got := rt.Count(window); got != expected.Count {
+			t.Errorf("Expected count %d in %v, got %d", expected.Count, window, got)
+		}
+
+		got, err := rt.Aggregate(window)
+		if err != nil {
+			t.Fatalf("Aggregate: %v", err)
+		}
+		if got.Count != expected.Count || math.Abs(got.Sum-expected.Sum) > 1e-6 || got.Min != expected.Min || got.Max != expected.Max {
+			t.Errorf("Expected %+v in %v, got %+v", expected, window, got)
+		}
+	}
+
+	// Counting the whole tree uses the root summary
+	rec.queries = nil
+	if got := rt.Count(*WholeWorld); got != len(remaining) {
+		t.Errorf("Expected %d entries, got %d", len(remaining), got)
+	}
+	if s := rec.queries[0]; s.Op != gortree.OpCount || s.NodesVisited != 1 || s.EntriesTested != 0 {
+		t.Errorf("Expected a single node visited, got %+v", s)
+	}
+
+	if err := rt.Validate(); err != nil {
+		t.Errorf("Invalid tree: %v", err)
+	}
+}
+
+func TestRTree_Count_Expired(t *testing.T) {
+
+	clock := &fakeClock{now: epoch}
+	rt, err := gortree.NewRTreeWithOptions(gortree.WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewRTreeWithOptions: %v", err)
+	}
+
+	for i, location := range cityLocations {
+		if i%2 == 0 {
+			_ = rt.InsertWithTTL(&location, time.Minute)
+		} else {
+			rt.Insert(&location)
+		}
+	}
+
+	if got := rt.Count(*WholeWorld); got != len(cityLocations) {
+		t.Errorf("Expected %d entries, got %d", len(cityLocations), got)
+	}
+
+	clock.Advance(2 * time.Minute)
+
+	if got, expected := rt.Count(*WholeWorld), len(rt.Query(*WholeWorld)); got != expected {
+		t.Errorf("Expected %d entries after expiry, got %d", expected, got)
+	}
+
+	if _, err := rt.Aggregate(*WholeWorld); err == nil {
+		t.Errorf("Expected error without aggregate function")
+	}
+}
@@ -0,0 +1,100 @@
+package gortree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// InsertBatch adds all the items to the tree under a single write lock. Nil items are skipped. When the batch is at
+// least as large as the tree, the whole tree is rebuilt by bulk loading instead of inserting one item at a time.
+func (t *RTree) InsertBatch(items []Spatial) {
+
+	t.mu.Lock()
+	defer t.unlock()
+
+	entries := make([]*node, 0, len(items))
+	for _, data := range items {
+		if data != nil {
+			entries = append(entries, t.newEntry(data))
+		}
+	}
+
+	if len(entries) >= t.size {
+		t.bulkLoad(append(t.collectLeafNodes(t.root), entries...))
+	} else {
+		for _, e := range entries {
+			t.insertNode(e)
+		}
+	}
+
+	t.size += len(entries)
+
+	for _, e := range entries {
+		t.notify(EventInsert, e.Data, Box{}, e.BoundingBox)
+	}
+}
+
+// DeleteBatch deletes all the items from the tree, by ID, under a single write lock. The returned slice holds the
+// error of each item, nil when it has been deleted. Entries are removed from their leaves first, then the tree is
+// condensed in a single pass, or rebuilt by bulk loading when at least half of it has been deleted.
+func (t *RTree) DeleteBatch(items []Spatial) []error {
+
+	t.mu.Lock()
+	defer t.unlock()
+
+	errs := make([]error, len(items))
+
+	var leaves []*node
+	removed := 0
+
+	for i, data := range items {
+
+		if data == nil {
+			errs[i] = errors.New("data is nil")
+			continue
+		}
+
+		entry, leaf := t.removeEntry(data)
+		if entry == nil {
+			errs[i] = fmt.Errorf("delete %s: node to delete not found", data.ID())
+			continue
+		}
+
+		removed++
+		leaves = append(leaves, leaf)
+
+		t.notify(EventDelete, entry.Data, entry.BoundingBox, Box{})
+	}
+
+	if removed == 0 {
+		return errs
+	}
+
+	if 2*removed >= t.size {
+		t.bulkLoad(t.collectLeafNodes(t.root))
+	} else if err := t.condenseAndReinsert(uniqueNodes(leaves)...); err != nil {
+		// The entries are already gone, report the failure on all of them
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = fmt.Errorf("delete %s: %w", items[i].ID(), err)
+			}
+		}
+	}
+
+	t.size -= removed
+
+	return errs
+}
+
+// uniqueNodes returns the nodes without duplicates, in order of first appearance.
+func uniqueNodes(nodes []*node) []*node {
+	seen := make(map[*node]bool, len(nodes))
+	unique := nodes[:0:0]
+	for _, n := range nodes {
+		if !seen[n] {
+			seen[n] = true
+			unique = append(unique, n)
+		}
+	}
+	return unique
+}
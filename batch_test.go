@@ -0,0 +1,115 @@
+package gortree_test
+
+import (
+	"testing"
+
+	"github.com/lambertmata/gortree"
+)
+
+func toSpatial(locations []*Location) []gortree.Spatial {
+	items := make([]gortree.Spatial, len(locations))
+	for i, l := range locations {
+		items[i] = l
+	}
+	return items
+}
+
+func TestRTree_InsertBatch(t *testing.T) {
+
+	hilbert, _ := gortree.NewRTreeWithOptions(gortree.WithHilbert(*WholeWorld))
+	wide, _ := gortree.NewRTreeWithOptions(gortree.WithMinMax(4, 16))
+
+	trees := []struct {
+		Name string
+		Tree *gortree.RTree
+	}{
+		{"Quadratic", gortree.NewRTree()},
+		{"Hilbert", hilbert},
+		{"Wide", wide},
+	}
+
+	locations := randomLocations(3000, 2)
+
+	queries := []gortree.Rect{
+		*WholeWorld,
+		*NorthAmerica,
+		*gortree.NewRect(-50, -20, 30, 45),
+	}
+
+	for _, tree := range trees {
+		t.Run(tree.Name, func(t *testing.T) {
+
+			rt := tree.Tree
+
+			// Bulk loaded into the empty tree
+			rt.InsertBatch(toSpatial(locations[:2000]))
+
+			// Inserted one by one
+			rt.InsertBatch(append(toSpatial(locations[2000:]), nil))
+
+			if rt.Len() != len(locations) {
+				t.Errorf("Expected %d entries, got %d", len(locations), rt.Len())
+			}
+
+			for _, q := range queries {
+				if got, expected := len(rt.Query(q)), bruteForceQuery(locations, q); got != expected {
+					t.Errorf("Expected %d entries in %v, got %d", expected, q, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRTree_DeleteBatch(t *testing.T) {
+
+	rt := gortree.NewRTree()
+
+	locations := randomLocations(2000, 3)
+	rt.InsertBatch(toSpatial(locations))
+
+	missing := &Location{Name: "missing"}
+
+	// A small batch condenses the tree
+	errs := rt.DeleteBatch(append(toSpatial(locations[:100]), missing, nil))
+
+	for i, err := range errs[:100] {
+		if err != nil {
+			t.Errorf("Expected %s to be deleted, got %v", locations[i].ID(), err)
+		}
+	}
+
+	if errs[100] == nil || errs[101] == nil {
+		t.Errorf("Expected errors for missing and nil items")
+	}
+
+	// A large batch rebuilds the tree
+	errs = rt.DeleteBatch(toSpatial(locations[100:1500]))
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	}
+
+	remaining := locations[1500:]
+
+	if rt.Len() != len(remaining) {
+		t.Errorf("Expected %d entries, got %d", len(remaining), rt.Len())
+	}
+
+	for _, q := range []gortree.Rect{*WholeWorld, *NorthAmerica} {
+		if got, expected := len(rt.Query(q)), bruteForceQuery(remaining, q); got != expected {
+			t.Errorf("Expected %d entries in %v, got %d", expected, q, got)
+		}
+	}
+
+	// The tree keeps working after the rebuild
+	for _, l := range remaining {
+		if err := rt.Delete(l); err != nil {
+			t.Fatalf("Delete %s: %v", l.ID(), err)
+		}
+	}
+
+	if got := len(rt.Entries()); got != 0 {
+		t.Errorf("Expected empty tree, got %d entries", got)
+	}
+}
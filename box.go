@@ -0,0 +1,127 @@
+package gortree
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaxDims is the maximum number of dimensions of a Box.
+const MaxDims = 4
+
+// Box is an axis-aligned box with up to MaxDims dimensions, such as (x, y, z) or (x, y, z, t). Only the first Dims
+// coordinates of Min and Max are meaningful, the others are zero.
+type Box struct {
+	Dims     int
+	Min, Max [MaxDims]float64
+}
+
+// SpatialBox is implemented by entries with more than two dimensions. The tree indexes the Box of these entries,
+// while BoundingBox is expected to return their projection on the first two dimensions.
+type SpatialBox interface {
+	Spatial
+	Box() Box
+}
+
+// NewBox creates a box from its min and max corners.
+func NewBox(min, max []float64) (*Box, error) {
+
+	if len(min) != len(max) {
+		return nil, fmt.Errorf("min has %d dimensions, max has %d", len(min), len(max))
+	}
+
+	if len(min) == 0 || len(min) > MaxDims {
+		return nil, fmt.Errorf("dims=%d (must satisfy 1 ≤ dims ≤ %d)", len(min), MaxDims)
+	}
+
+	b := &Box{Dims: len(min)}
+	copy(b.Min[:], min)
+	copy(b.Max[:], max)
+
+	return b, nil
+}
+
+// Box Returns the rect as a two-dimensional box
+func (r *Rect) Box() Box {
+	return Box{
+		Dims: 2,
+		Min:  [MaxDims]float64{r.MinX, r.MinY},
+		Max:  [MaxDims]float64{r.MaxX, r.MaxY},
+	}
+}
+
+// Rect Returns the projection of the box on the first two dimensions
+func (b *Box) Rect() Rect {
+	return Rect{
+		MinX: b.Min[0], MinY: b.Min[1],
+		MaxX: b.Max[0], MaxY: b.Max[1],
+	}
+}
+
+// withDims Returns the box with exactly dims dimensions, dropping the extra ones or adding zero-width ones at 0
+func (b Box) withDims(dims int) Box {
+	for i := dims; i < b.Dims; i++ {
+		b.Min[i] = 0
+		b.Max[i] = 0
+	}
+	b.Dims = dims
+	return b
+}
+
+// Expand Expands the current box to contain otherBox. Dimensions missing from either box are taken as zero
+func (b *Box) Expand(otherBox Box) {
+	b.Dims = max(b.Dims, otherBox.Dims)
+	for i := 0; i < b.Dims; i++ {
+		b.Min[i] = math.Min(b.Min[i], otherBox.Min[i])
+		b.Max[i] = math.Max(b.Max[i], otherBox.Max[i])
+	}
+}
+
+// Contains Checks if a box contains another. Dimensions missing from either box are unconstrained
+func (b *Box) Contains(other Box) bool {
+	for i := 0; i < min(b.Dims, other.Dims); i++ {
+		if b.Min[i] > other.Min[i] || b.Max[i] < other.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects Checks if a box intersects another. Dimensions missing from either box are unconstrained
+func (b *Box) Intersects(other Box) bool {
+	for i := 0; i < min(b.Dims, other.Dims); i++ {
+		if b.Min[i] > other.Max[i] || b.Max[i] < other.Min[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Area Returns the volume of the box, which is its area in two dimensions
+func (b *Box) Area() float64 {
+	if b.Dims == 0 {
+		return 0
+	}
+	volume := 1.0
+	for i := 0; i < b.Dims; i++ {
+		volume *= b.Max[i] - b.Min[i]
+	}
+	return volume
+}
+
+// Enlargement Returns the volume enlargement required to contain otherBox
+func (b *Box) Enlargement(otherBox Box) float64 {
+	volume := b.Area()
+	expandedBox := *b
+	expandedBox.Expand(otherBox)
+	expandedVolume := expandedBox.Area()
+	return expandedVolume - volume
+}
+
+// boxOf returns the box indexed for data, with the given number of dimensions.
+func boxOf(data Spatial, dims int) Box {
+	if sb, ok := data.(SpatialBox); ok {
+		return sb.Box().withDims(dims)
+	}
+	r := data.BoundingBox()
+	return r.Box().withDims(dims)
+}
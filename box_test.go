@@ -0,0 +1,129 @@
+package gortree_test
+
+import (
+	"testing"
+
+	"github.com/lambertmata/gortree"
+)
+
+type Drone struct {
+	Name     string
+	Position [3]float64
+}
+
+func (d *Drone) ID() string {
+	return d.Name
+}
+
+func (d *Drone) BoundingBox() gortree.Rect {
+	return *gortree.NewRect(d.Position[0], d.Position[1], d.Position[0], d.Position[1])
+}
+
+func (d *Drone) Box() gortree.Box {
+	box, _ := gortree.NewBox(d.Position[:], d.Position[:])
+	return *box
+}
+
+func mustBox(t *testing.T, min, max []float64) gortree.Box {
+	t.Helper()
+	box, err := gortree.NewBox(min, max)
+	if err != nil {
+		t.Fatalf("NewBox: %v", err)
+	}
+	return *box
+}
+
+func TestNewBox(t *testing.T) {
+
+	if _, err := gortree.NewBox([]float64{0, 0}, []float64{1, 1, 1}); err == nil {
+		t.Errorf("Expected error for mismatched dimensions")
+	}
+
+	if _, err := gortree.NewBox(make([]float64, 5), make([]float64, 5)); err == nil {
+		t.Errorf("Expected error for too many dimensions")
+	}
+
+	box := mustBox(t, []float64{0, 1, 2}, []float64{3, 4, 5})
+	if box.Dims != 3 || box.Min[2] != 2 || box.Max[2] != 5 {
+		t.Errorf("Expected 3D box (0,1,2)-(3,4,5), got %v", box)
+	}
+}
+
+func TestBox(t *testing.T) {
+
+	box := mustBox(t, []float64{0, 0, 0}, []float64{10, 10, 10})
+
+	if volume := box.Area(); volume != 1000 {
+		t.Errorf("Expected volume 1000, got %f", volume)
+	}
+
+	if !box.Contains(mustBox(t, []float64{1, 1, 1}, []float64{9, 9, 9})) {
+		t.Errorf("Expected box to contain inner box")
+	}
+
+	if box.Intersects(mustBox(t, []float64{1, 1, 11}, []float64{2, 2, 12})) {
+		t.Errorf("Expected box not to intersect box above it")
+	}
+
+	// The z dimension is unconstrained for a 2D box
+	flat := gortree.NewRect(5, 5, 20, 20).Box()
+	if !box.Intersects(flat) {
+		t.Errorf("Expected 3D box to intersect 2D box")
+	}
+
+	if enlargement := box.Enlargement(mustBox(t, []float64{0, 0, 0}, []float64{10, 10, 20})); enlargement != 1000 {
+		t.Errorf("Expected enlargement 1000, got %f", enlargement)
+	}
+
+	box.Expand(mustBox(t, []float64{-5, 0, 0}, []float64{0, 0, 0}))
+	if box.Min[0] != -5 {
+		t.Errorf("Expected expanded min x -5, got %f", box.Min[0])
+	}
+
+	if r := box.Rect(); r != *gortree.NewRect(-5, 0, 10, 10) {
+		t.Errorf("Expected projection (-5,0,10,10), got %v", r)
+	}
+}
+
+func TestRTree_3D(t *testing.T) {
+
+	rt, err := gortree.NewRTreeWithOptions(gortree.WithDims(3))
+	if err != nil {
+		t.Fatalf("NewRTreeWithOptions: %v", err)
+	}
+
+	if rt.Dims() != 3 {
+		t.Errorf("Expected 3 dimensions, got %d", rt.Dims())
+	}
+
+	var drones []*Drone
+	for i := 0; i < 100; i++ {
+		d := &Drone{
+			Name:     string(rune('A'+i%26)) + string(rune('0'+i/26)),
+			Position: [3]float64{float64(i % 10), float64(i / 10), float64(i)},
+		}
+		drones = append(drones, d)
+		rt.Insert(d)
+	}
+
+	// Low altitude drones only
+	low := mustBox(t, []float64{0, 0, 0}, []float64{10, 10, 49})
+	if got := len(rt.QueryBox(low)); got != 50 {
+		t.Errorf("Expected 50 drones below 50, got %d", got)
+	}
+
+	// A 2D query matches every altitude
+	if got := len(rt.Query(*gortree.NewRect(0, 0, 10, 10))); got != 100 {
+		t.Errorf("Expected 100 drones in 2D query, got %d", got)
+	}
+
+	for _, d := range drones[:50] {
+		if err := rt.Delete(d); err != nil {
+			t.Fatalf("Delete %s: %v", d.ID(), err)
+		}
+	}
+
+	if got := len(rt.QueryBox(low)); got != 0 {
+		t.Errorf("Expected no drones below 50 after delete, got %d", got)
+	}
+}
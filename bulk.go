@@ -0,0 +1,105 @@
+package gortree
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// bulkLoad replaces the tree content with the given entry nodes, packing them bottom-up. In ModeQuadratic nodes are
+// packed with Sort-Tile-Recursive, in ModeHilbert following the Hilbert order. Requires t.mu held for writing.
+func (t *RTree) bulkLoad(entries []*node) {
+
+	if len(entries) == 0 {
+		t.root = &node{IsLeaf: true}
+		t.updateNodeMBR(t.root)
+		return
+	}
+
+	level := entries
+	isLeaf := true
+
+	for {
+
+		var groups [][]*node
+		if t.mode == ModeHilbert {
+			slices.SortStableFunc(level, func(a, b *node) int {
+				return cmp.Compare(a.LHV, b.LHV)
+			})
+			groups = t.chunk(level)
+		} else {
+			groups = t.tile(level, 0)
+		}
+
+		parents := make([]*node, len(groups))
+		for i, g := range groups {
+			parents[i] = &node{
+				IsLeaf:   isLeaf,
+				Children: g,
+			}
+			t.adjustEntriesParent(parents[i])
+			t.updateNodeMBR(parents[i])
+		}
+
+		if len(parents) == 1 {
+			t.root = parents[0]
+			t.root.Parent = nil
+			return
+		}
+
+		level = parents
+		isLeaf = false
+	}
+}
+
+// tile groups nodes with Sort-Tile-Recursive: nodes are sorted by the center along dim and cut into slabs, then each
+// slab is tiled along the following dimension. Along the last dimension slabs are cut into nodes.
+func (t *RTree) tile(nodes []*node, dim int) [][]*node {
+
+	slices.SortFunc(nodes, func(a, b *node) int {
+		return cmp.Compare(a.BoundingBox.Min[dim]+a.BoundingBox.Max[dim], b.BoundingBox.Min[dim]+b.BoundingBox.Max[dim])
+	})
+
+	if dim == t.dims-1 || len(nodes) <= t.maxEntries {
+		return t.chunk(nodes)
+	}
+
+	// The nodes to fill are spread on a grid with the same number of slabs along each remaining dimension
+	pages := math.Ceil(float64(len(nodes)) / float64(t.maxEntries))
+	slabs := int(math.Ceil(math.Pow(pages, 1/float64(t.dims-dim))))
+
+	// Every slab must be able to fill at least one node
+	slabs = max(1, min(slabs, len(nodes)/t.minEntries))
+
+	var groups [][]*node
+	for _, slab := range split(nodes, slabs) {
+		groups = append(groups, t.tile(slab, dim+1)...)
+	}
+
+	return groups
+}
+
+// chunk cuts nodes, in order, into the fewest groups of at most maxEntries, all of similar size.
+func (t *RTree) chunk(nodes []*node) [][]*node {
+	count := (len(nodes) + t.maxEntries - 1) / t.maxEntries
+	return split(nodes, max(count, 1))
+}
+
+// split cuts nodes into count groups whose sizes differ by at most one. The groups are copies, so that they can be
+// grown independently.
+func split(nodes []*node, count int) [][]*node {
+
+	groups := make([][]*node, 0, count)
+	start := 0
+
+	for i := 0; i < count; i++ {
+		size := len(nodes) / count
+		if i < len(nodes)%count {
+			size++
+		}
+		groups = append(groups, slices.Clone(nodes[start:start+size]))
+		start += size
+	}
+
+	return groups
+}
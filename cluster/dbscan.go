@@ -0,0 +1,76 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/lambertmata/gortree"
+)
+
+// Noise is the DBSCAN label of the entries that belong to no cluster.
+const Noise = -1
+
+// DBSCAN clusters the entries of the tree by density: entries with at least minPts entries, themselves included,
+// within eps of their center are core entries, and core entries within eps of each other share a cluster, along with
+// the non-core entries within eps of them. Distances are measured from the center of an entry to the bounding boxes of
+// the others, the distance between them for point entries. The result maps every entry ID to its cluster, numbered
+// from 0, or to Noise. Neighbourhoods are found with a radius query on the tree, once per entry.
+func DBSCAN(rt *gortree.RTree, eps float64, minPts int) (map[string]int, error) {
+
+	if eps <= 0 {
+		return nil, fmt.Errorf("eps %v must be positive", eps)
+	}
+	if minPts < 1 {
+		return nil, fmt.Errorf("min points %d must be at least 1", minPts)
+	}
+
+	entries := rt.Entries()
+	labels := make(map[string]int, len(entries))
+
+	neighbours := func(e gortree.Spatial) []gortree.Spatial {
+		b := e.BoundingBox()
+		return rt.QueryRadius(gortree.Point{(b.MinX + b.MaxX) / 2, (b.MinY + b.MaxY) / 2}, eps)
+	}
+
+	cluster := 0
+
+	for _, e := range entries {
+
+		if _, ok := labels[e.ID()]; ok {
+			continue
+		}
+
+		seeds := neighbours(e)
+		if len(seeds) < minPts {
+			// May still be reached later from a core entry
+			labels[e.ID()] = Noise
+			continue
+		}
+
+		labels[e.ID()] = cluster
+		queue := gortree.NewStackFrom(seeds...)
+
+		for !queue.Empty() {
+
+			n, _ := queue.Pop()
+
+			label, ok := labels[n.ID()]
+			if ok && label != Noise {
+				continue
+			}
+			labels[n.ID()] = cluster
+
+			// Noise entries were already found not to be core
+			if ok {
+				continue
+			}
+
+			if more := neighbours(n); len(more) >= minPts {
+				queue.Push(more...)
+			}
+		}
+
+		cluster++
+	}
+
+	return labels, nil
+}
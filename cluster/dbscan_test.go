@@ -0,0 +1,102 @@
+package cluster_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/cluster"
+)
+
+func TestDBSCAN(t *testing.T) {
+
+	rnd := rand.New(rand.NewPCG(5, 5))
+	rt := gortree.NewRTree()
+
+	var places []*place
+
+	// Three dense blobs
+	for i := 0; i < 300; i++ {
+		cx, cy := float64(i%3)*50, 0.0
+		places = append(places, &place{id: fmt.Sprintf("b%d", i), lng: cx + rnd.NormFloat64(), lat: cy + rnd.NormFloat64()})
+	}
+
+	// Isolated entries
+	for i := 0; i < 5; i++ {
+		places = append(places, &place{id: fmt.Sprintf("n%d", i), lng: float64(i)*30 - 60, lat: 80})
+	}
+
+	for _, p := range places {
+		rt.Insert(p)
+	}
+
+	const eps, minPts = 1.5, 5
+
+	labels, err := cluster.DBSCAN(rt, eps, minPts)
+	if err != nil {
+		t.Fatalf("DBSCAN: %v", err)
+	}
+
+	if len(labels) != len(places) {
+		t.Fatalf("DBSCAN labelled %d entries, want %d", len(labels), len(places))
+	}
+
+	for i := 0; i < 5; i++ {
+		if l := labels[fmt.Sprintf("n%d", i)]; l != cluster.Noise {
+			t.Errorf("isolated entry n%d in cluster %d, want noise", i, l)
+		}
+	}
+
+	// The blobs are three distinct clusters, whatever their number
+	blobs := make(map[int]int)
+	for i := 0; i < 3; i++ {
+		l := labels[fmt.Sprintf("b%d", i)]
+		if l == cluster.Noise {
+			t.Fatalf("blob %d center entry is noise", i)
+		}
+		blobs[l] = i
+	}
+	if len(blobs) != 3 {
+		t.Errorf("blobs in %d clusters, want 3", len(blobs))
+	}
+
+	// Core entries, found by brute force, share the cluster of the core entries within eps
+	distance := func(a, b *place) float64 { return math.Hypot(a.lng-b.lng, a.lat-b.lat) }
+
+	core := make(map[*place]bool)
+	for _, a := range places {
+		n := 0
+		for _, b := range places {
+			if distance(a, b) <= eps {
+				n++
+			}
+		}
+		core[a] = n >= minPts
+	}
+
+	for _, a := range places {
+		if !core[a] {
+			continue
+		}
+		if labels[a.id] == cluster.Noise {
+			t.Fatalf("core entry %s is noise", a.id)
+		}
+		for _, b := range places {
+			if distance(a, b) <= eps && core[b] && labels[a.id] != labels[b.id] {
+				t.Fatalf("core entries %s and %s in clusters %d and %d", a.id, b.id, labels[a.id], labels[b.id])
+			}
+		}
+	}
+}
+
+func TestDBSCAN_Invalid(t *testing.T) {
+
+	if _, err := cluster.DBSCAN(gortree.NewRTree(), 0, 3); err == nil {
+		t.Error("DBSCAN with eps 0, want an error")
+	}
+	if _, err := cluster.DBSCAN(gortree.NewRTree(), 1, 0); err == nil {
+		t.Error("DBSCAN with min points 0, want an error")
+	}
+}
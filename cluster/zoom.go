@@ -0,0 +1,278 @@
+// Package cluster groups the entries of a gortree.RTree.
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/geom"
+)
+
+// Options configures the zoom clustering of an Index. Zero values are replaced by the defaults.
+type Options struct {
+	MinZoom   int     // Lowest zoom level with clusters, 0 by default
+	MaxZoom   int     // Highest zoom level with clusters, 16 by default. Above it, entries are never clustered
+	Radius    float64 // Cluster radius in pixels, 40 by default
+	Extent    float64 // Tile extent in pixels, the radius is relative to it, 512 by default
+	MinPoints int     // Entries needed to form a cluster, 2 by default
+}
+
+// Cluster is a cluster of entries, or a single entry left out of clusters, at a zoom level.
+type Cluster struct {
+	ID       string          // Cluster ID, to expand it with Children, or the entry ID for a single entry
+	Centroid geom.Point      // Longitude and latitude of the centroid of the entries
+	Count    int             // Entries in the cluster, 1 for a single entry
+	Entry    gortree.Spatial // The single entry, nil for clusters
+}
+
+// IsCluster tells whether c groups several entries.
+func (c Cluster) IsCluster() bool {
+	return c.Entry == nil
+}
+
+// point is a cluster or a single entry in Web Mercator coordinates, scaled to [0, 1].
+type point struct {
+	id       string
+	x, y     float64
+	count    int
+	entry    gortree.Spatial
+	children []*point // Points of the next zoom level grouped in the cluster
+	zoom     int      // Last zoom level the point was clustered at, to skip it once processed
+}
+
+func (p *point) ID() string {
+	return p.id
+}
+
+func (p *point) BoundingBox() gortree.Rect {
+	return *gortree.NewRect(p.x, p.y, p.x, p.y)
+}
+
+// cluster converts the point to its public form.
+func (p *point) cluster() Cluster {
+	return Cluster{
+		ID:       p.id,
+		Centroid: geom.Point{unprojectX(p.x), unprojectY(p.y)},
+		Count:    p.count,
+		Entry:    p.entry,
+	}
+}
+
+// Index clusters the entries of a tree at every zoom level of a web map, in the style of supercluster. The entries
+// are expected in longitude and latitude degrees, each located at the center of its bounding box. The index is a
+// snapshot: it doesn't follow later changes of the tree.
+type Index struct {
+	opts     Options
+	levels   []*gortree.RTree // Points of each zoom level, from MinZoom to MaxZoom+1 where entries are single
+	clusters map[string]*point
+}
+
+// NewIndex clusters the entries of the tree. Starting from the entries, each zoom level, from MaxZoom down to MinZoom,
+// groups the points of the level above that are within Radius pixels of each other into clusters located at their
+// weighted centroid.
+func NewIndex(rt *gortree.RTree, opts Options) (*Index, error) {
+
+	if opts.MaxZoom == 0 {
+		opts.MaxZoom = 16
+	}
+	if opts.Radius == 0 {
+		opts.Radius = 40
+	}
+	if opts.Extent == 0 {
+		opts.Extent = 512
+	}
+	if opts.MinPoints == 0 {
+		opts.MinPoints = 2
+	}
+
+	switch {
+	case opts.MinZoom < 0 || opts.MaxZoom > 30 || opts.MinZoom > opts.MaxZoom:
+		return nil, fmt.Errorf("zoom levels min=%d, max=%d (must satisfy 0 ≤ min ≤ max ≤ 30)", opts.MinZoom, opts.MaxZoom)
+	case opts.Radius < 0 || opts.Extent < 0:
+		return nil, errors.New("radius and extent must be positive")
+	case opts.MinPoints < 2:
+		return nil, fmt.Errorf("min points %d must be at least 2", opts.MinPoints)
+	}
+
+	idx := &Index{
+		opts:     opts,
+		levels:   make([]*gortree.RTree, opts.MaxZoom-opts.MinZoom+2),
+		clusters: make(map[string]*point),
+	}
+
+	entries := rt.Entries()
+	points := make([]*point, len(entries))
+	for i, e := range entries {
+		bbox := e.BoundingBox()
+		points[i] = &point{
+			id:    e.ID(),
+			x:     projectX((bbox.MinX + bbox.MaxX) / 2),
+			y:     projectY((bbox.MinY + bbox.MaxY) / 2),
+			count: 1,
+			entry: e,
+			zoom:  math.MaxInt,
+		}
+	}
+
+	idx.levels[len(idx.levels)-1] = newLevel(points)
+
+	for z := opts.MaxZoom; z >= opts.MinZoom; z-- {
+		points = idx.clusterLevel(points, z)
+		idx.levels[z-opts.MinZoom] = newLevel(points)
+	}
+
+	return idx, nil
+}
+
+// newLevel indexes the points of a zoom level.
+func newLevel(points []*point) *gortree.RTree {
+	items := make([]gortree.Spatial, len(points))
+	for i, p := range points {
+		items[i] = p
+	}
+	rt := gortree.NewRTree()
+	rt.InsertBatch(items)
+	return rt
+}
+
+// clusterLevel groups the points of zoom z+1 into the points of zoom z.
+func (idx *Index) clusterLevel(points []*point, z int) []*point {
+
+	above := idx.levels[z+1-idx.opts.MinZoom]
+	radius := idx.opts.Radius / (idx.opts.Extent * math.Exp2(float64(z)))
+
+	var result []*point
+
+	for _, p := range points {
+
+		// Already grouped into a cluster at this zoom
+		if p.zoom <= z {
+			continue
+		}
+		p.zoom = z
+
+		members := []*point{p}
+		count := p.count
+
+		for _, item := range above.QueryRadius(gortree.Point{p.x, p.y}, radius) {
+			n := item.(*point)
+			if n.zoom > z {
+				members = append(members, n)
+				count += n.count
+			}
+		}
+
+		if count < idx.opts.MinPoints {
+			result = append(result, p)
+			continue
+		}
+
+		c := &point{
+			id:       fmt.Sprintf("cluster-%d-%d", z, len(idx.clusters)),
+			count:    count,
+			children: members,
+			zoom:     math.MaxInt,
+		}
+
+		for _, m := range members {
+			m.zoom = z
+			c.x += m.x * float64(m.count)
+			c.y += m.y * float64(m.count)
+		}
+		c.x /= float64(count)
+		c.y /= float64(count)
+
+		idx.clusters[c.id] = c
+		result = append(result, c)
+	}
+
+	return result
+}
+
+// Clusters returns the clusters and single entries in bbox, in longitude and latitude degrees, at the zoom level.
+// Zoom levels are clamped to [MinZoom, MaxZoom+1]. A bbox whose MinX is greater than its MaxX crosses the
+// antimeridian.
+func (idx *Index) Clusters(bbox gortree.Rect, zoom int) []Cluster {
+
+	zoom = max(idx.opts.MinZoom, min(zoom, idx.opts.MaxZoom+1))
+	level := idx.levels[zoom-idx.opts.MinZoom]
+
+	// Web Mercator y grows southwards
+	minY, maxY := projectY(bbox.MaxY), projectY(bbox.MinY)
+
+	var items []gortree.Spatial
+	if bbox.MinX <= bbox.MaxX {
+		items = level.Query(*gortree.NewRect(projectX(bbox.MinX), minY, projectX(bbox.MaxX), maxY))
+	} else {
+		items = append(level.Query(*gortree.NewRect(projectX(bbox.MinX), minY, 1, maxY)),
+			level.Query(*gortree.NewRect(0, minY, projectX(bbox.MaxX), maxY))...)
+	}
+
+	clusters := make([]Cluster, len(items))
+	for i, item := range items {
+		clusters[i] = item.(*point).cluster()
+	}
+
+	return clusters
+}
+
+// Children returns the clusters and single entries a cluster is made of, at the next zoom level.
+func (idx *Index) Children(clusterID string) ([]Cluster, error) {
+
+	c, ok := idx.clusters[clusterID]
+	if !ok {
+		return nil, fmt.Errorf("cluster %q not found", clusterID)
+	}
+
+	children := make([]Cluster, len(c.children))
+	for i, child := range c.children {
+		children[i] = child.cluster()
+	}
+
+	return children, nil
+}
+
+// Leaves returns all the entries of a cluster.
+func (idx *Index) Leaves(clusterID string) ([]gortree.Spatial, error) {
+
+	c, ok := idx.clusters[clusterID]
+	if !ok {
+		return nil, fmt.Errorf("cluster %q not found", clusterID)
+	}
+
+	entries := make([]gortree.Spatial, 0, c.count)
+	stack := gortree.NewStackFrom(c.children...)
+
+	for !stack.Empty() {
+		p, _ := stack.Pop()
+		if p.entry != nil {
+			entries = append(entries, p.entry)
+		} else {
+			stack.Push(p.children...)
+		}
+	}
+
+	return entries, nil
+}
+
+// projectX maps the longitude to [0, 1].
+func projectX(lng float64) float64 {
+	return lng/360 + 0.5
+}
+
+// projectY maps the latitude to [0, 1] with the Web Mercator projection, from north to south.
+func projectY(lat float64) float64 {
+	sin := math.Sin(lat * math.Pi / 180)
+	y := 0.5 - 0.25*math.Log((1+sin)/(1-sin))/math.Pi
+	return max(0, min(y, 1))
+}
+
+func unprojectX(x float64) float64 {
+	return (x - 0.5) * 360
+}
+
+func unprojectY(y float64) float64 {
+	return 360*math.Atan(math.Exp((180-y*360)*math.Pi/180))/math.Pi - 90
+}
@@ -0,0 +1,179 @@
+package cluster_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/cluster"
+)
+
+// place is a point entry in longitude and latitude degrees.
+type place struct {
+	id       string
+	lng, lat float64
+}
+
+func (p *place) ID() string {
+	return p.id
+}
+
+func (p *place) BoundingBox() gortree.Rect {
+	return *gortree.NewRect(p.lng, p.lat, p.lng, p.lat)
+}
+
+var world = *gortree.NewRect(-180, -85, 180, 85)
+
+// randomTree indexes n places around a few random centers.
+func randomTree(n int, seed uint64) *gortree.RTree {
+
+	rnd := rand.New(rand.NewPCG(seed, seed))
+	rt := gortree.NewRTree()
+
+	for i := 0; i < n; i++ {
+		cx, cy := float64(i%5)*60-120, float64(i%3)*40-40
+		rt.Insert(&place{id: fmt.Sprintf("p%d", i), lng: cx + rnd.NormFloat64()*5, lat: cy + rnd.NormFloat64()*5})
+	}
+
+	return rt
+}
+
+func total(clusters []cluster.Cluster) int {
+	n := 0
+	for _, c := range clusters {
+		n += c.Count
+	}
+	return n
+}
+
+func TestIndex_Clusters(t *testing.T) {
+
+	rt := randomTree(1000, 3)
+
+	idx, err := cluster.NewIndex(rt, cluster.Options{MaxZoom: 12})
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+
+	previous := 0
+	for z := 0; z <= 13; z++ {
+
+		clusters := idx.Clusters(world, z)
+		if got := total(clusters); got != 1000 {
+			t.Fatalf("zoom %d: clusters count %d entries, want 1000", z, got)
+		}
+		if len(clusters) < previous {
+			t.Errorf("zoom %d: %d clusters, fewer than the %d of the zoom below", z, len(clusters), previous)
+		}
+		previous = len(clusters)
+	}
+
+	if got := len(idx.Clusters(world, 0)); got > 30 {
+		t.Errorf("zoom 0: %d clusters, want at most 30", got)
+	}
+
+	for _, c := range idx.Clusters(world, 13) {
+		if c.IsCluster() || c.Count != 1 || c.ID != c.Entry.ID() {
+			t.Fatalf("zoom 13: %+v, want single entries", c)
+		}
+	}
+}
+
+func TestIndex_Children(t *testing.T) {
+
+	rt := randomTree(500, 7)
+
+	idx, err := cluster.NewIndex(rt, cluster.Options{})
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+
+	seen := make(map[string]bool)
+
+	for _, c := range idx.Clusters(world, 2) {
+
+		if !c.IsCluster() {
+			continue
+		}
+
+		children, err := idx.Children(c.ID)
+		if err != nil {
+			t.Fatalf("Children(%s): %v", c.ID, err)
+		}
+		if got := total(children); got != c.Count {
+			t.Errorf("Children(%s) count %d entries, want %d", c.ID, got, c.Count)
+		}
+
+		leaves, err := idx.Leaves(c.ID)
+		if err != nil {
+			t.Fatalf("Leaves(%s): %v", c.ID, err)
+		}
+		if len(leaves) != c.Count {
+			t.Errorf("Leaves(%s) = %d entries, want %d", c.ID, len(leaves), c.Count)
+		}
+
+		// The centroid is the mean of the entries
+		var lng float64
+		for _, l := range leaves {
+			if seen[l.ID()] {
+				t.Errorf("entry %s in several clusters", l.ID())
+			}
+			seen[l.ID()] = true
+			lng += l.(*place).lng
+		}
+		if mean := lng / float64(len(leaves)); math.Abs(mean-c.Centroid[0]) > 1e-9 {
+			t.Errorf("cluster %s centroid longitude %v, want %v", c.ID, c.Centroid[0], mean)
+		}
+	}
+
+	if _, err := idx.Children("p1"); err == nil {
+		t.Error("Children of a single entry, want an error")
+	}
+}
+
+func TestIndex_Zoom(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	rt.Insert(&place{id: "a", lng: 10, lat: 45})
+	rt.Insert(&place{id: "b", lng: 10.01, lat: 45})
+	rt.Insert(&place{id: "c", lng: 179.9, lat: 0})
+
+	idx, err := cluster.NewIndex(rt, cluster.Options{})
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+
+	clusters := idx.Clusters(*gortree.NewRect(0, 40, 20, 50), 5)
+	if len(clusters) != 1 || clusters[0].Count != 2 {
+		t.Fatalf("zoom 5: %+v, want a cluster of a and b", clusters)
+	}
+	if c := clusters[0].Centroid; math.Abs(c[0]-10.005) > 1e-9 || math.Abs(c[1]-45) > 1e-9 {
+		t.Errorf("centroid %v, want [10.005 45]", c)
+	}
+
+	if got := len(idx.Clusters(*gortree.NewRect(0, 40, 20, 50), 16)); got != 2 {
+		t.Errorf("zoom 16: %d clusters, want a and b apart", got)
+	}
+
+	// A viewport crossing the antimeridian
+	if got := idx.Clusters(*gortree.NewRect(170, -10, -170, 10), 3); len(got) != 1 || got[0].ID != "c" {
+		t.Errorf("antimeridian viewport: %+v, want c", got)
+	}
+}
+
+func TestNewIndex_Invalid(t *testing.T) {
+
+	for _, opts := range []cluster.Options{
+		{MinZoom: 5, MaxZoom: 2},
+		{MinZoom: -1},
+		{MaxZoom: 40},
+		{MinPoints: 1},
+		{Radius: -1},
+	} {
+		if _, err := cluster.NewIndex(gortree.NewRTree(), opts); err == nil {
+			t.Errorf("NewIndex(%+v), want an error", opts)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/geojson"
+)
+
+// newFlagSet creates the flag set of a command, printing its errors and usage to stderr.
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: gortree %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the flags of a command taking a single file argument, and returns it.
+func parse(fs *flag.FlagSet, args []string) (string, error) {
+
+	// The flag package has already printed the error and the usage
+	if err := fs.Parse(args); err != nil {
+		return "", errUsage
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return "", errUsage
+	}
+
+	return fs.Arg(0), nil
+}
+
+// createFile creates the file at path, or returns stdout when path is "-" or empty. The returned function closes the
+// file, and must be called to check for write errors.
+func createFile(path string, stdout io.Writer) (io.Writer, func() error, error) {
+
+	if path == "" || path == "-" {
+		return stdout, func() error { return nil }, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, file.Close, nil
+}
+
+// load reads the input file into a tree and saves it as an index file.
+func load(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	fs := newFlagSet("load", "input", stderr)
+	format := fs.String("format", "", "input format: csv, geojson or ndjson, guessed from the file extension when empty")
+	idName := fs.String("id", "", "column or property holding the entry IDs")
+	output := fs.String("o", "", "index file to write")
+	minEntries := fs.Int("min", 8, "min entries of the tree nodes")
+	maxEntries := fs.Int("max", 32, "max entries of the tree nodes")
+	hilbert := fs.Bool("hilbert", false, "build a Hilbert R-tree over the bounding box of the entries")
+
+	input, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		fmt.Fprintln(stderr, "gortree load: missing -o flag")
+		fs.Usage()
+		return errUsage
+	}
+
+	// Check the tree parameters before reading a possibly large input
+	if _, err := gortree.NewRTreeWithOptions(gortree.WithMinMax(*minEntries, *maxEntries)); err != nil {
+		return fmt.Errorf("invalid -min %d and -max %d: %w", *minEntries, *maxEntries, err)
+	}
+
+	if *format, err = inputFormat(*format, input); err != nil {
+		return err
+	}
+
+	r := stdin
+	if input != "-" {
+		file, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var entries []gortree.Spatial
+	ids := make(map[string]bool)
+
+	err = readFeatures(bufio.NewReader(r), *format, *idName, func(f *geojson.Feature) error {
+		if ids[f.ID()] {
+			return fmt.Errorf("duplicate id %q", f.ID())
+		}
+		ids[f.ID()] = true
+		entries = append(entries, f)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("load %s: %w", input, err)
+	}
+
+	header := indexHeader{Min: uint32(*minEntries), Max: uint32(*maxEntries)}
+	if *hilbert {
+		header.Mode = uint8(gortree.ModeHilbert)
+		header.Domain = hilbertDomain(entries)
+	}
+
+	rt, err := header.newTree()
+	if err != nil {
+		return err
+	}
+	rt.InsertBatch(entries)
+
+	if err := writeIndex(*output, header, rt); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stderr, "loaded %d entries into %s\n", len(entries), *output)
+
+	return nil
+}
+
+// query runs a rect, radius or kNN query against an index file.
+func query(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	fs := newFlagSet("query", "index", stderr)
+	rect := fs.String("rect", "", "entries intersecting the rectangle `minx,miny,maxx,maxy`")
+	radius := fs.String("radius", "", "entries within distance r of the point `x,y,r`")
+	knn := fs.String("knn", "", "k entries nearest to the point `x,y,k`")
+	format := fs.String("format", "ndjson", "output format: ids, ndjson or geojson")
+
+	path, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	set := 0
+	for _, q := range []string{*rect, *radius, *knn} {
+		if q != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		fmt.Fprintln(stderr, "gortree query: set exactly one of -rect, -radius and -knn")
+		fs.Usage()
+		return errUsage
+	}
+
+	switch *format {
+	case "ids", "ndjson", "geojson":
+	default:
+		return fmt.Errorf("unsupported output format %q", *format)
+	}
+
+	var run func(rt *gortree.RTree) []gortree.Spatial
+
+	switch {
+	case *rect != "":
+		c, err := parseFloats(*rect)
+		if err != nil || len(c) != 4 {
+			return fmt.Errorf("invalid -rect %q, expected minx,miny,maxx,maxy", *rect)
+		}
+		run = func(rt *gortree.RTree) []gortree.Spatial {
+			return rt.Query(*gortree.NewRect(c[0], c[1], c[2], c[3]))
+		}
+	case *radius != "":
+		c, err := parseFloats(*radius)
+		if err != nil || len(c) != 3 || c[2] < 0 {
+			return fmt.Errorf("invalid -radius %q, expected x,y,r", *radius)
+		}
+		run = func(rt *gortree.RTree) []gortree.Spatial {
+			return rt.QueryRadius(gortree.Point{c[0], c[1]}, c[2])
+		}
+	default:
+		c, err := parseFloats(*knn)
+		if err != nil || len(c) != 3 || c[2] < 1 || c[2] != float64(int(c[2])) {
+			return fmt.Errorf("invalid -knn %q, expected x,y,k", *knn)
+		}
+		run = func(rt *gortree.RTree) []gortree.Spatial {
+			return rt.Nearest(gortree.Point{c[0], c[1]}, int(c[2]))
+		}
+	}
+
+	rt, err := openIndex(path)
+	if err != nil {
+		return err
+	}
+
+	results := run(rt)
+	w := bufio.NewWriter(stdout)
+
+	switch *format {
+	case "ids":
+		for _, item := range results {
+			fmt.Fprintln(w, item.ID())
+		}
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, item := range results {
+			if err := enc.Encode(geojson.FromSpatial(item)); err != nil {
+				return err
+			}
+		}
+	case "geojson":
+		if err := geojson.WriteFeatureCollection(w, results); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// stats prints the statistics of the tree of an index file, rebuilt as load built it.
+func stats(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	fs := newFlagSet("stats", "index", stderr)
+
+	path, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	rt, err := openIndex(path)
+	if err != nil {
+		return err
+	}
+
+	s := rt.Stats()
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "entries\t%d\n", s.Entries)
+	fmt.Fprintf(tw, "height\t%d\n", s.Height)
+	fmt.Fprintf(tw, "nodes\t%d\n", s.Nodes)
+	fmt.Fprintf(tw, "leaves\t%d\n", s.Leaves)
+	fmt.Fprintf(tw, "mode\t%s\n", rt.Mode())
+	fmt.Fprintf(tw, "min entries\t%d\n", rt.Min())
+	fmt.Fprintf(tw, "max entries\t%d\n", rt.Max())
+	fmt.Fprintf(tw, "min fill\t%d\n", s.MinFill)
+	fmt.Fprintf(tw, "max fill\t%d\n", s.MaxFill)
+	fmt.Fprintf(tw, "avg fill\t%.1f%%\n", s.AvgFill*100)
+	fmt.Fprintf(tw, "underfull\t%d\n", s.Underfull)
+	fmt.Fprintf(tw, "overlap\t%g\n", s.Overlap)
+	fmt.Fprintf(tw, "coverage\t%g\n", s.Coverage)
+
+	return tw.Flush()
+}
+
+// validate checks the invariants of the tree of an index file, rebuilt as load built it. It finds the index files that
+// don't decode, and the bugs of the bulk loading with the parameters of the file.
+func validate(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	fs := newFlagSet("validate", "index", stderr)
+
+	path, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	rt, err := openIndex(path)
+	if err != nil {
+		return err
+	}
+
+	if err := rt.Validate(); err != nil {
+		return fmt.Errorf("invalid tree:\n%w", err)
+	}
+
+	fmt.Fprintf(stdout, "ok, %d entries\n", rt.Len())
+
+	return nil
+}
+
+// render writes an SVG image of the tree of an index file.
+func render(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	fs := newFlagSet("render", "index", stderr)
+	width := fs.Int("width", 800, "image width in pixels")
+	output := fs.String("o", "-", "SVG file to write, standard output when -")
+
+	path, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	rt, err := openIndex(path)
+	if err != nil {
+		return err
+	}
+
+	w, closeFile, err := createFile(*output, stdout)
+	if err != nil {
+		return err
+	}
+
+	if err := rt.WriteSVG(w, gortree.SVGOptions{Width: *width, Margin: 10}); err != nil {
+		closeFile()
+		return err
+	}
+
+	return closeFile()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/geojson"
+)
+
+const (
+	indexMagic   = "GRTI"
+	indexVersion = 1
+)
+
+// indexHeader precedes the snapshot of the entries in an index file. It holds the parameters of the tree built by
+// load, so that the other commands rebuild the same tree.
+type indexHeader struct {
+	Magic    [4]byte
+	Version  uint8
+	Mode     uint8 // gortree.Mode
+	Min, Max uint32
+	Domain   [4]float64 // Hilbert domain minx, miny, maxx, maxy, zero in ModeQuadratic
+}
+
+// newTree creates an empty tree with the parameters of the header.
+func (h indexHeader) newTree() (*gortree.RTree, error) {
+
+	opts := []gortree.Option{gortree.WithMinMax(int(h.Min), int(h.Max))}
+
+	switch gortree.Mode(h.Mode) {
+	case gortree.ModeQuadratic:
+	case gortree.ModeHilbert:
+		opts = append(opts, gortree.WithHilbert(*gortree.NewRect(h.Domain[0], h.Domain[1], h.Domain[2], h.Domain[3])))
+	default:
+		return nil, fmt.Errorf("unsupported tree mode %d", h.Mode)
+	}
+
+	return gortree.NewRTreeWithOptions(opts...)
+}
+
+// hilbertDomain returns the bounding box of the entries, widened when flat so that it can be a Hilbert domain.
+func hilbertDomain(entries []gortree.Spatial) [4]float64 {
+
+	if len(entries) == 0 {
+		return [4]float64{0, 0, 1, 1}
+	}
+
+	domain := entries[0].BoundingBox()
+	for _, e := range entries[1:] {
+		domain.Expand(e.BoundingBox())
+	}
+
+	if domain.MaxX <= domain.MinX {
+		domain.MinX, domain.MaxX = domain.MinX-0.5, domain.MaxX+0.5
+	}
+	if domain.MaxY <= domain.MinY {
+		domain.MinY, domain.MaxY = domain.MinY-0.5, domain.MaxY+0.5
+	}
+
+	return [4]float64{domain.MinX, domain.MinY, domain.MaxX, domain.MaxY}
+}
+
+// writeIndex writes the header and the entries of the tree to the index file at path. The file is written next to
+// path, then renamed, so that a failure doesn't leave a truncated index behind.
+func writeIndex(path string, header indexHeader, rt *gortree.RTree) error {
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	copy(header.Magic[:], indexMagic)
+	header.Version = indexVersion
+
+	w := bufio.NewWriter(tmp)
+	err = binary.Write(w, binary.LittleEndian, header)
+	if err == nil {
+		err = rt.WriteSnapshot(w, geojson.Codec{})
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// openIndex rebuilds the tree of the index file at path, bulk loading its entries with the parameters of its header.
+func openIndex(path string) (*gortree.RTree, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+
+	var header indexHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("read %s: not an index file", path)
+		}
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	if string(header.Magic[:]) != indexMagic {
+		return nil, fmt.Errorf("read %s: not an index file", path)
+	}
+	if header.Version != indexVersion {
+		return nil, fmt.Errorf("read %s: unsupported index version %d", path, header.Version)
+	}
+
+	rt, err := header.newTree()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	if err := rt.ReadSnapshot(r, geojson.Codec{}); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	return rt, nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/geojson"
+	"github.com/lambertmata/gortree/geom"
+	"github.com/lambertmata/gortree/wkt"
+)
+
+// inputFormat returns the format of the input file, as set or guessed from its extension.
+func inputFormat(format, path string) (string, error) {
+
+	if format != "" {
+		switch format {
+		case "csv", "geojson", "ndjson":
+			return format, nil
+		}
+		return "", fmt.Errorf("unsupported input format %q", format)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return "csv", nil
+	case ".geojson", ".json":
+		return "geojson", nil
+	case ".ndjson", ".jsonl":
+		return "ndjson", nil
+	}
+
+	return "", fmt.Errorf("cannot guess the format of %q, set it with -format", path)
+}
+
+// readFeatures decodes the input, calling fn with each feature.
+func readFeatures(r io.Reader, format, idName string, fn func(*geojson.Feature) error) error {
+	switch format {
+	case "csv":
+		return readCSV(r, idName, fn)
+	case "geojson":
+		return geojson.ReadFeatureCollection(r, geojson.Options{IDProperty: idName}, fn)
+	default:
+		return geojson.ReadFeatureSequence(r, geojson.Options{IDProperty: idName}, fn)
+	}
+}
+
+// readCSV decodes CSV rows as features. The columns are described in the package documentation.
+func readCSV(r io.Reader, idName string, fn func(*geojson.Feature) error) error {
+
+	if idName == "" {
+		idName = "id"
+	}
+
+	cr := csv.NewReader(r)
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("read csv: missing header")
+		}
+		return fmt.Errorf("read csv: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	// lookup returns the index of the first column found among names
+	lookup := func(names ...string) (int, bool) {
+		for _, name := range names {
+			if i, ok := columns[name]; ok {
+				return i, true
+			}
+		}
+		return -1, false
+	}
+
+	idColumn, ok := lookup(strings.ToLower(idName))
+	if !ok {
+		return fmt.Errorf("read csv: missing %q column", idName)
+	}
+
+	wktColumn, hasWKT := lookup("wkt")
+	xColumn, hasX := lookup("x", "lon", "lng", "longitude")
+	yColumn, hasY := lookup("y", "lat", "latitude")
+	boxColumns := make([]int, 4)
+	hasBox := true
+	for i, name := range []string{"minx", "miny", "maxx", "maxy"} {
+		if boxColumns[i], ok = lookup(name); !ok {
+			hasBox = false
+		}
+	}
+
+	// geometry decodes the geometry of a row
+	var geometry func(row []string) (geom.Geometry, error)
+
+	switch {
+	case hasWKT:
+		geometry = func(row []string) (geom.Geometry, error) {
+			return wkt.Parse(row[wktColumn])
+		}
+	case hasX && hasY:
+		geometry = func(row []string) (geom.Geometry, error) {
+			c, err := parseFloats(row[xColumn], row[yColumn])
+			if err != nil {
+				return nil, err
+			}
+			return geom.Point{c[0], c[1]}, nil
+		}
+	case hasBox:
+		geometry = func(row []string) (geom.Geometry, error) {
+			c, err := parseFloats(row[boxColumns[0]], row[boxColumns[1]], row[boxColumns[2]], row[boxColumns[3]])
+			if err != nil {
+				return nil, err
+			}
+			return geom.FromRect(*gortree.NewRect(c[0], c[1], c[2], c[3])), nil
+		}
+	default:
+		return errors.New("read csv: missing geometry columns, expected wkt, x and y, or minx, miny, maxx and maxy")
+	}
+
+	for line := 2; ; line++ {
+
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read csv: %w", err)
+		}
+
+		g, err := geometry(row)
+		if err == nil {
+			err = checkGeometry(g)
+		}
+		if err != nil {
+			return fmt.Errorf("read csv line %d: %w", line, err)
+		}
+
+		f := &geojson.Feature{FeatureID: row[idColumn], Geometry: g, Properties: map[string]any{}}
+		if f.FeatureID == "" {
+			return fmt.Errorf("read csv line %d: empty id", line)
+		}
+
+		for i, value := range row {
+			if i != idColumn && i != wktColumn {
+				f.Properties[header[i]] = value
+			}
+		}
+
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+}
+
+// checkGeometry rejects the geometries that can't be indexed or written to the index file as GeoJSON: empty
+// geometries, coordinates that are not finite and geometry collections.
+func checkGeometry(g geom.Geometry) error {
+
+	if _, ok := g.(geom.GeometryCollection); ok {
+		return errors.New("geometry collections are not supported")
+	}
+
+	b := g.Bounds()
+	for _, v := range []float64{b.MinX, b.MinY, b.MaxX, b.MaxY} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return errors.New("empty geometry or coordinates not finite")
+		}
+	}
+
+	return nil
+}
+
+// parseFloats parses comma separated numbers, or each value given.
+func parseFloats(values ...string) ([]float64, error) {
+
+	if len(values) == 1 {
+		values = strings.Split(values[0], ",")
+	}
+
+	result := make([]float64, len(values))
+	for i, v := range values {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", v)
+		}
+		result[i] = f
+	}
+
+	return result, nil
+}
@@ -0,0 +1,101 @@
+// Command gortree builds, queries and inspects R-tree index files.
+//
+// Usage:
+//
+//	gortree load [-format csv|geojson|ndjson] [-id name] [-min n] [-max n] [-hilbert] -o index.grt input
+//	gortree query [-rect minx,miny,maxx,maxy | -radius x,y,r | -knn x,y,k] [-format ids|ndjson|geojson] index.grt
+//	gortree stats index.grt
+//	gortree validate index.grt
+//	gortree render [-width px] [-o out.svg] index.grt
+//
+// The load command bulk loads the entries into a tree with -min and -max entries per node, 8 and 32 by default, in
+// ModeHilbert over the bounding box of the entries with -hilbert.
+//
+// # Input formats
+//
+// The input of load is read from a file, or from standard input when it is "-". Its format is guessed from the file
+// extension (.csv, .geojson or .json, .ndjson or .jsonl) unless set with -format.
+//
+//   - csv: comma separated values with a header row. The geometry of each row is taken from a wkt column holding Well-Known
+//     Text, from x and y (or lon and lat) columns for points, or from minx, miny, maxx and maxy columns for
+//     rectangles. Empty geometries, geometry collections and coordinates that are not finite are rejected. The ID
+//     comes from the id column, or the column named by -id. Other columns are kept as string properties.
+//   - geojson: a GeoJSON FeatureCollection.
+//   - ndjson: newline-delimited GeoJSON, one Feature per line.
+//
+// For geojson and ndjson, the ID is the feature id, or the property named by -id.
+//
+// # Index files
+//
+// An index file is a header holding the min and max entries, the mode and the Hilbert domain of the tree, followed by
+// a gortree snapshot, as written by RTree.WriteSnapshot, holding one GeoJSON Feature per entry. The nodes aren't
+// stored: the other commands rebuild the tree by bulk loading the entries with the parameters of the header, as load
+// built it. So stats and render describe that rebuilt tree, and validate checks it, which fails on files that don't
+// decode.
+//
+// # Output formats
+//
+// The query results are written to standard output, ordered by distance for -knn, as:
+//
+//   - ids: one entry ID per line.
+//   - ndjson: one GeoJSON Feature per line, the default.
+//   - geojson: a GeoJSON FeatureCollection.
+//
+// The stats command prints one metric per line, validate prints the violated invariants and exits with status 1 when
+// there are any, and render writes an SVG image of the nodes and entries.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// errUsage is returned for invalid command lines, after the usage has been printed.
+var errUsage = errors.New("invalid usage")
+
+const usage = `Usage:
+  gortree load [-format csv|geojson|ndjson] [-id name] [-min n] [-max n] [-hilbert] -o index.grt input
+  gortree query [-rect minx,miny,maxx,maxy | -radius x,y,r | -knn x,y,k] [-format ids|ndjson|geojson] index.grt
+  gortree stats index.grt
+  gortree validate index.grt
+  gortree render [-width px] [-o out.svg] index.grt
+
+Run gortree <command> -h for the flags of a command.
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "gortree:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command line args.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+
+	commands := map[string]func(args []string, stdin io.Reader, stdout, stderr io.Writer) error{
+		"load":     load,
+		"query":    query,
+		"stats":    stats,
+		"validate": validate,
+		"render":   render,
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "gortree: unknown command %q\n%s", args[0], usage)
+		return errUsage
+	}
+
+	return command(args[1:], stdin, stdout, stderr)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const citiesCSV = `id,lat,lon,country
+genova,44.4071,8.9282,IT
+milan,45.4675,9.1918,IT
+rome,41.8967,12.4822,IT
+paris,48.8566,2.3522,FR
+london,51.5072,-0.1275,UK
+new-york,40.7128,-74.0060,US
+`
+
+const parksNDJSON = `{"type": "Feature", "id": "hyde", "properties": {"name": "Hyde Park"}, "geometry": {"type": "Polygon", "coordinates": [[[-0.19, 51.50], [-0.15, 51.50], [-0.15, 51.51], [-0.19, 51.51], [-0.19, 51.50]]]}}
+{"type": "Feature", "id": "central", "properties": {"name": "Central Park"}, "geometry": {"type": "Polygon", "coordinates": [[[-73.98, 40.76], [-73.95, 40.80], [-73.94, 40.79], [-73.97, 40.76], [-73.98, 40.76]]]}}
+`
+
+// runCLI runs the command line, returning its standard output and error.
+func runCLI(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(args, strings.NewReader(""), &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadQuery(t *testing.T) {
+
+	dir := t.TempDir()
+	index := filepath.Join(dir, "cities.grt")
+
+	if _, _, err := runCLI(t, "load", "-o", index, writeFile(t, dir, "cities.csv", citiesCSV)); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	testCases := []struct {
+		Name     string
+		Args     []string
+		Expected string
+	}{
+		{"Rect", []string{"-rect", "8,44,10,46"}, "genova\nmilan\n"},
+		{"Radius", []string{"-radius", "9,44.5,0.5"}, "genova\n"},
+		{"KNN", []string{"-knn", "0,50,3"}, "london\nparis\nmilan\n"},
+	}
+
+	for _, testCase := range testCases {
+
+		args := append([]string{"query", "-format", "ids"}, testCase.Args...)
+		out, _, err := runCLI(t, append(args, index)...)
+		if err != nil {
+			t.Fatalf("query %s: %v", testCase.Name, err)
+		}
+
+		// Rect and radius results are unordered
+		if testCase.Name != "KNN" {
+			lines := strings.Split(strings.TrimSpace(out), "\n")
+			if len(lines) == 2 && lines[0] > lines[1] {
+				out = lines[1] + "\n" + lines[0] + "\n"
+			}
+		}
+
+		if out != testCase.Expected {
+			t.Errorf("Expected %q for %s, got %q", testCase.Expected, testCase.Name, out)
+		}
+	}
+
+	// Features keep the other columns as properties
+	out, _, err := runCLI(t, "query", "-knn", "2,49,1", index)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	var feature struct {
+		ID         string
+		Properties map[string]any
+	}
+	if err := json.Unmarshal([]byte(out), &feature); err != nil {
+		t.Fatalf("Invalid ndjson output %q: %v", out, err)
+	}
+	if feature.ID != "paris" || feature.Properties["country"] != "FR" {
+		t.Errorf("Expected paris in FR, got %+v", feature)
+	}
+}
+
+func TestLoad_Formats(t *testing.T) {
+
+	dir := t.TempDir()
+
+	collection := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"code": "a"}, "geometry": {"type": "Point", "coordinates": [1, 1]}},
+		{"type": "Feature", "properties": {"code": "b"}, "geometry": {"type": "Point", "coordinates": [2, 2]}}
+	]}`
+
+	wktCSV := "name,wkt\nroad,\"LINESTRING (0 0, 10 10)\"\nbox,\"POLYGON ((20 20, 30 20, 30 30, 20 20))\"\n"
+
+	testCases := []struct {
+		Name  string
+		Args  []string
+		Query string
+		IDs   string
+	}{
+		{"NDJSON", []string{writeFile(t, dir, "parks.ndjson", parksNDJSON)}, "-0.17,51.505,-0.17,51.505", "hyde\n"},
+		{"GeoJSON", []string{"-id", "code", writeFile(t, dir, "points.geojson", collection)}, "1.5,1.5,3,3", "b\n"},
+		{"WKT CSV", []string{"-id", "name", writeFile(t, dir, "shapes.csv", wktCSV)}, "5,5,6,6", "road\n"},
+		{"Format flag", []string{"-format", "ndjson", writeFile(t, dir, "parks.txt", parksNDJSON)}, "-74,40,-73,41", "central\n"},
+	}
+
+	for _, testCase := range testCases {
+
+		index := filepath.Join(dir, "index.grt")
+		args := append([]string{"load", "-o", index}, testCase.Args...)
+		if _, _, err := runCLI(t, args...); err != nil {
+			t.Fatalf("load %s: %v", testCase.Name, err)
+		}
+
+		out, _, err := runCLI(t, "query", "-format", "ids", "-rect", testCase.Query, index)
+		if err != nil {
+			t.Fatalf("query %s: %v", testCase.Name, err)
+		}
+		if out != testCase.IDs {
+			t.Errorf("Expected %q for %s, got %q", testCase.IDs, testCase.Name, out)
+		}
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+
+	dir := t.TempDir()
+	index := filepath.Join(dir, "index.grt")
+
+	testCases := []struct {
+		Name  string
+		File  string
+		Input string
+	}{
+		{"Unknown extension", "input.txt", citiesCSV},
+		{"Missing geometry", "input.csv", "id,name\na,b\n"},
+		{"Bad number", "input.csv", "id,x,y\na,1,b\n"},
+		{"Duplicate id", "input.csv", "id,x,y\na,1,1\na,2,2\n"},
+		{"Bad feature", "input.ndjson", `{"type": "Feature", "id": "a"}`},
+		{"Empty geometry", "input.csv", "id,wkt\na,POINT (1 2)\nb,LINESTRING EMPTY\n"},
+		{"Geometry collection", "input.csv", "id,wkt\na,POINT (1 2)\nb,GEOMETRYCOLLECTION (POINT (1 2))\n"},
+		{"NaN coordinate", "input.csv", "id,x,y\na,1,1\nb,NaN,1\n"},
+		{"Infinite coordinate", "input.csv", "id,x,y\na,1,1\nb,1,Inf\n"},
+		{"Infinite box", "input.csv", "id,minx,miny,maxx,maxy\na,0,0,1,1\nb,0,0,1,-Inf\n"},
+	}
+
+	for _, testCase := range testCases {
+		if _, _, err := runCLI(t, "load", "-o", index, writeFile(t, dir, testCase.File, testCase.Input)); err == nil {
+			t.Errorf("Expected error for %s", testCase.Name)
+		}
+	}
+
+	// Invalid rows are reported with their line
+	_, _, err := runCLI(t, "load", "-o", index, writeFile(t, dir, "input.csv", "id,wkt\na,POINT (1 2)\nb,LINESTRING EMPTY\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected error at line 3, got %v", err)
+	}
+
+	// Tree parameters are checked before reading the input
+	if _, _, err := runCLI(t, "load", "-o", index, "-min", "10", "-max", "4", filepath.Join(dir, "missing.csv")); err == nil || !strings.Contains(err.Error(), "-min") {
+		t.Errorf("Expected -min and -max error, got %v", err)
+	}
+
+	if _, err := os.Stat(index); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected no index written, got %v", err)
+	}
+}
+
+func TestStatsValidateRender(t *testing.T) {
+
+	dir := t.TempDir()
+	index := filepath.Join(dir, "cities.grt")
+
+	if _, _, err := runCLI(t, "load", "-min", "2", "-max", "4", "-o", index, writeFile(t, dir, "cities.csv", citiesCSV)); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// The tree is rebuilt with the parameters given to load
+	out, _, err := runCLI(t, "stats", index)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	for _, line := range []string{"entries      6\n", "height       2\n", "mode         quadratic\n", "min entries  2\n", "max entries  4\n"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("Expected %q in stats %q", line, out)
+		}
+	}
+
+	hilbert := filepath.Join(dir, "hilbert.grt")
+	if _, _, err := runCLI(t, "load", "-hilbert", "-o", hilbert, writeFile(t, dir, "cities.csv", citiesCSV)); err != nil {
+		t.Fatalf("load -hilbert: %v", err)
+	}
+	if out, _, err := runCLI(t, "stats", hilbert); err != nil || !strings.Contains(out, "mode         hilbert\n") || !strings.Contains(out, "max entries  32\n") {
+		t.Errorf("Expected a Hilbert tree with 32 max entries, got %q, %v", out, err)
+	}
+
+	if _, _, err := runCLI(t, "stats", filepath.Join(dir, "cities.csv")); err == nil || !strings.Contains(err.Error(), "not an index file") {
+		t.Errorf("Expected stats of a CSV file to fail, got %v", err)
+	}
+
+	out, _, err = runCLI(t, "validate", index)
+	if err != nil || out != "ok, 6 entries\n" {
+		t.Errorf("Expected valid tree, got %q, %v", out, err)
+	}
+
+	svg := filepath.Join(dir, "cities.svg")
+	if _, _, err := runCLI(t, "render", "-o", svg, index); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	b, err := os.ReadFile(svg)
+	if err != nil || !bytes.HasPrefix(b, []byte("<svg")) || bytes.Count(b, []byte(`class="entry"`)) != 6 {
+		t.Errorf("Expected SVG with 6 entries, got %s", b)
+	}
+}
+
+func TestUsage(t *testing.T) {
+
+	testCases := [][]string{
+		{},
+		{"unknown"},
+		{"query", "-rect", "0,0,1,1", "-knn", "0,0,1", "index.grt"},
+		{"query", "index.grt"},
+		{"load", "input.csv"},
+		{"stats"},
+		{"stats", "-bogus", "index.grt"},
+	}
+
+	for _, args := range testCases {
+		if _, stderr, err := runCLI(t, args...); !errors.Is(err, errUsage) || stderr == "" {
+			t.Errorf("Expected usage error for %v, got %v", args, err)
+		}
+	}
+
+	if _, _, err := runCLI(t, "stats", "missing.grt"); err == nil || errors.Is(err, errUsage) {
+		t.Errorf("Expected error for missing index, got %v", err)
+	}
+}
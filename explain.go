@@ -0,0 +1,178 @@
+package gortree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Outcome tells what a query did with a node or an entry.
+type Outcome int
+
+const (
+	// OutcomePruned is a node skipped because its bounding box doesn't intersect the query.
+	OutcomePruned Outcome = iota
+	// OutcomeDescended is a node whose children were examined.
+	OutcomeDescended
+	// OutcomeMatched is an entry intersecting the query, returned.
+	OutcomeMatched
+	// OutcomeMissed is an entry not intersecting the query.
+	OutcomeMissed
+	// OutcomeExpired is an entry intersecting the query, hidden because its TTL is over.
+	OutcomeExpired
+)
+
+// String returns the outcome name.
+func (o Outcome) String() string {
+	switch o {
+	case OutcomePruned:
+		return "pruned"
+	case OutcomeDescended:
+		return "descended"
+	case OutcomeMatched:
+		return "matched"
+	case OutcomeMissed:
+		return "missed"
+	case OutcomeExpired:
+		return "expired"
+	default:
+		return fmt.Sprintf("Outcome(%d)", int(o))
+	}
+}
+
+// Trace is a node visited by a query, as returned by QueryExplain.
+type Trace struct {
+	BoundingBox Box
+	Depth       int  // 0 for the root
+	Leaf        bool // Whether the node is a leaf, whose children are entries
+	Outcome     Outcome
+	Children    []*Trace     // Children of a descended internal node
+	Entries     []EntryTrace // Entries of a descended leaf
+}
+
+// EntryTrace is an entry tested by a query.
+type EntryTrace struct {
+	Data        Spatial
+	BoundingBox Box
+	Outcome     Outcome
+}
+
+// QueryExplain runs Query and also returns the trace of the nodes it visited, from the root: which were pruned and
+// which descended, and the outcome of every entry tested. Nodes with many entries tested for few matches are the
+// overlap hot spots of the tree.
+func (t *RTree) QueryExplain(r Rect) ([]Spatial, *Trace) {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	results := make([]Spatial, 0)
+	trace := t.explain(t.root, r.Box(), 0, t.now(), &results)
+
+	return results, trace
+}
+
+// explain traces the query r on the subtree of n, adding the matching entries to results. Requires t.mu held.
+func (t *RTree) explain(n *node, r Box, depth int, now int64, results *[]Spatial) *Trace {
+
+	trace := &Trace{
+		BoundingBox: n.BoundingBox,
+		Depth:       depth,
+		Leaf:        n.IsLeaf,
+		Outcome:     OutcomePruned,
+	}
+
+	if !n.BoundingBox.Intersects(r) {
+		return trace
+	}
+
+	trace.Outcome = OutcomeDescended
+
+	if !n.IsLeaf {
+		for _, child := range n.Children {
+			trace.Children = append(trace.Children, t.explain(child, r, depth+1, now, results))
+		}
+		return trace
+	}
+
+	for _, e := range n.Children {
+
+		outcome := OutcomeMissed
+		switch {
+		case !e.BoundingBox.Intersects(r):
+		case expired(e, now):
+			outcome = OutcomeExpired
+		default:
+			outcome = OutcomeMatched
+			*results = append(*results, e.Data)
+		}
+
+		trace.Entries = append(trace.Entries, EntryTrace{Data: e.Data, BoundingBox: e.BoundingBox, Outcome: outcome})
+	}
+
+	return trace
+}
+
+// Counts returns the number of nodes visited, nodes pruned, entries tested and entries matched in the trace.
+func (tr *Trace) Counts() (visited, pruned, tested, matched int) {
+
+	stack := NewStackFrom(tr)
+
+	for !stack.Empty() {
+
+		cur, _ := stack.Pop()
+		visited++
+
+		if cur.Outcome == OutcomePruned {
+			pruned++
+		}
+
+		for _, e := range cur.Entries {
+			tested++
+			if e.Outcome == OutcomeMatched {
+				matched++
+			}
+		}
+
+		stack.Push(cur.Children...)
+	}
+
+	return visited, pruned, tested, matched
+}
+
+// String renders the trace as an indented tree, one line per node and entry.
+func (tr *Trace) String() string {
+	var b strings.Builder
+	tr.write(&b)
+	return b.String()
+}
+
+func (tr *Trace) write(b *strings.Builder) {
+
+	indent := strings.Repeat("  ", tr.Depth)
+	kind := "node"
+	if tr.Leaf {
+		kind = "leaf"
+	}
+
+	fmt.Fprintf(b, "%s%s %s %s\n", indent, kind, formatBox(tr.BoundingBox), tr.Outcome)
+
+	for _, e := range tr.Entries {
+		fmt.Fprintf(b, "%s  entry %s %s %s\n", indent, e.Data.ID(), formatBox(e.BoundingBox), e.Outcome)
+	}
+
+	for _, child := range tr.Children {
+		child.write(b)
+	}
+}
+
+// formatBox formats the box as [min max], such as [0 0, 10 10].
+func formatBox(b Box) string {
+	return fmt.Sprintf("[%s, %s]", formatCoords(b.Min[:b.Dims]), formatCoords(b.Max[:b.Dims]))
+}
+
+func formatCoords(c []float64) string {
+	parts := make([]string, len(c))
+	for i, v := range c {
+		parts[i] = fmt.Sprintf("%g", v)
+	}
+	return strings.Join(parts, " ")
+}
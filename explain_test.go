@@ -0,0 +1,107 @@
+package gortree_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lambertmata/gortree"
+)
+
+func TestRTree_QueryExplain(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	locations := randomLocations(300, 8)
+	for _, l := range locations {
+		rt.Insert(l)
+	}
+
+	window := *gortree.NewRect(-30, -30, 30, 30)
+
+	results, trace := rt.QueryExplain(window)
+
+	if got, expected := ids(results), ids(rt.Query(window)); !slices.Equal(got, expected) {
+		t.Fatalf("Expected the results of Query, got %d results instead of %d", len(got), len(expected))
+	}
+
+	if trace.Depth != 0 || trace.Leaf || trace.Outcome != gortree.OutcomeDescended {
+		t.Errorf("Expected descended internal root, got %+v", trace)
+	}
+
+	visited, pruned, tested, matched := trace.Counts()
+	if matched != len(results) || pruned == 0 || tested <= matched || visited <= pruned {
+		t.Errorf("Unexpected counts: visited %d, pruned %d, tested %d, matched %d", visited, pruned, tested, matched)
+	}
+
+	// Pruned nodes don't intersect the window and have no children traced, descended leaves trace all their entries
+	var check func(tr *gortree.Trace)
+	check = func(tr *gortree.Trace) {
+
+		bbox := tr.BoundingBox.Rect()
+
+		switch tr.Outcome {
+		case gortree.OutcomePruned:
+			if bbox.Intersects(window) || len(tr.Children) > 0 || len(tr.Entries) > 0 {
+				t.Errorf("Unexpected pruned node %+v", tr)
+			}
+		case gortree.OutcomeDescended:
+			if !bbox.Intersects(window) || (tr.Leaf && len(tr.Entries) == 0) || (!tr.Leaf && len(tr.Children) == 0) {
+				t.Errorf("Unexpected descended node %+v", tr)
+			}
+		}
+
+		for _, e := range tr.Entries {
+			entryBox := e.BoundingBox.Rect()
+			if (e.Outcome == gortree.OutcomeMatched) != entryBox.Intersects(window) {
+				t.Errorf("Unexpected outcome %s for %s", e.Outcome, e.Data.ID())
+			}
+		}
+
+		for _, child := range tr.Children {
+			if child.Depth != tr.Depth+1 {
+				t.Errorf("Expected depth %d, got %d", tr.Depth+1, child.Depth)
+			}
+			check(child)
+		}
+	}
+	check(trace)
+
+	// The rendering has a line per traced node and entry
+	if lines := strings.Count(trace.String(), "\n"); lines != visited+tested {
+		t.Errorf("Expected %d lines, got %d", visited+tested, lines)
+	}
+}
+
+func TestRTree_QueryExplain_Expired(t *testing.T) {
+
+	clock := &fakeClock{now: epoch}
+	rt, err := gortree.NewRTreeWithOptions(gortree.WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewRTreeWithOptions: %v", err)
+	}
+
+	_ = rt.InsertWithTTL(&cityLocations[0], time.Minute)
+	rt.Insert(&cityLocations[1])
+
+	clock.Advance(2 * time.Minute)
+
+	results, trace := rt.QueryExplain(*WholeWorld)
+
+	if len(results) != 1 || results[0].ID() != cityLocations[1].Name {
+		t.Errorf("Expected only %s, got %v", cityLocations[1].Name, ids(results))
+	}
+
+	outcomes := map[string]gortree.Outcome{}
+	for _, e := range trace.Entries {
+		outcomes[e.Data.ID()] = e.Outcome
+	}
+
+	if outcomes[cityLocations[0].Name] != gortree.OutcomeExpired || outcomes[cityLocations[1].Name] != gortree.OutcomeMatched {
+		t.Errorf("Unexpected outcomes %v", outcomes)
+	}
+
+	if !strings.Contains(trace.String(), "entry Genova [8.928275776757602 44.40716297481325, 8.928275776757602 44.40716297481325] expired") {
+		t.Errorf("Unexpected rendering\n%s", trace)
+	}
+}
@@ -0,0 +1,198 @@
+// Package geofence turns a stream of device positions into enter, exit and dwell events against fences indexed by a
+// gortree.RTree.
+package geofence
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/geom"
+)
+
+// Region is implemented by fences with an exact shape. Candidates found through the tree that don't implement Region
+// are refined by their bounding box alone.
+type Region interface {
+	gortree.Spatial
+	ContainsPoint(p geom.Point) bool
+}
+
+// Fence is a polygonal fence.
+type Fence struct {
+	Name    string
+	Polygon geom.Polygon
+}
+
+// ID returns the fence name.
+func (f *Fence) ID() string {
+	return f.Name
+}
+
+// BoundingBox returns the bounds of the polygon.
+func (f *Fence) BoundingBox() gortree.Rect {
+	return f.Polygon.Bounds()
+}
+
+// ContainsPoint tells whether the point is inside the polygon.
+func (f *Fence) ContainsPoint(p geom.Point) bool {
+	return f.Polygon.ContainsPoint(p)
+}
+
+// EventKind is the kind of membership transition.
+type EventKind int
+
+const (
+	// Exit is emitted when a device leaves a fence.
+	Exit EventKind = iota
+	// Enter is emitted when a device enters a fence.
+	Enter
+	// Dwell is emitted once when a device has stayed inside a fence for the configured dwell time.
+	Dwell
+)
+
+// String returns the kind name.
+func (k EventKind) String() string {
+	switch k {
+	case Exit:
+		return "exit"
+	case Enter:
+		return "enter"
+	case Dwell:
+		return "dwell"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a membership transition of a device.
+type Event struct {
+	Kind     EventKind
+	DeviceID string
+	FenceID  string
+	Position geom.Point
+	Time     time.Time
+}
+
+// Options configures a Geofencer.
+type Options struct {
+	// Dwell is the time a device must stay inside a fence before a Dwell event is emitted. Zero disables dwell events.
+	Dwell time.Duration
+}
+
+// membership is the state of a device inside a fence.
+type membership struct {
+	since   time.Time
+	dwelled bool
+}
+
+// Geofencer tracks which fences each device is in.
+type Geofencer struct {
+	mu      sync.Mutex
+	fences  *gortree.RTree
+	opts    Options
+	devices map[string]map[string]*membership
+}
+
+// New creates a Geofencer matching positions against the fences stored in rt. Fences can be added to and removed from
+// rt at any time, changes are picked up by the following updates.
+func New(rt *gortree.RTree, opts Options) *Geofencer {
+	return &Geofencer{
+		fences:  rt,
+		opts:    opts,
+		devices: make(map[string]map[string]*membership),
+	}
+}
+
+// Update records the position of the device at the given time and returns the resulting events: exits first, then
+// enters and dwells, each ordered by fence ID.
+func (g *Geofencer) Update(deviceID string, p geom.Point, at time.Time) []Event {
+
+	inside := g.fencesAt(p)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	state := g.devices[deviceID]
+	if state == nil {
+		state = make(map[string]*membership)
+		g.devices[deviceID] = state
+	}
+
+	var events []Event
+
+	newEvent := func(kind EventKind, fenceID string) Event {
+		return Event{Kind: kind, DeviceID: deviceID, FenceID: fenceID, Position: p, Time: at}
+	}
+
+	for fenceID := range state {
+		if !slices.Contains(inside, fenceID) {
+			delete(state, fenceID)
+			events = append(events, newEvent(Exit, fenceID))
+		}
+	}
+
+	for _, fenceID := range inside {
+
+		m, ok := state[fenceID]
+		if !ok {
+			m = &membership{since: at}
+			state[fenceID] = m
+			events = append(events, newEvent(Enter, fenceID))
+		}
+
+		if g.opts.Dwell > 0 && !m.dwelled && at.Sub(m.since) >= g.opts.Dwell {
+			m.dwelled = true
+			events = append(events, newEvent(Dwell, fenceID))
+		}
+	}
+
+	if len(state) == 0 {
+		delete(g.devices, deviceID)
+	}
+
+	slices.SortStableFunc(events, func(a, b Event) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.FenceID, b.FenceID))
+	})
+
+	return events
+}
+
+// fencesAt returns the IDs of the fences containing the point. Candidates are found with a query on the tree, then
+// refined exactly.
+func (g *Geofencer) fencesAt(p geom.Point) []string {
+
+	var ids []string
+
+	for _, candidate := range g.fences.Query(p.Bounds()) {
+		if region, ok := candidate.(Region); ok && !region.ContainsPoint(p) {
+			continue
+		}
+		ids = append(ids, candidate.ID())
+	}
+
+	return ids
+}
+
+// Inside returns the IDs of the fences the device is currently in, sorted.
+func (g *Geofencer) Inside(deviceID string) []string {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ids := make([]string, 0, len(g.devices[deviceID]))
+	for fenceID := range g.devices[deviceID] {
+		ids = append(ids, fenceID)
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+// Forget drops the state of the device without emitting events.
+func (g *Geofencer) Forget(deviceID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.devices, deviceID)
+}
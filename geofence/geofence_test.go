@@ -0,0 +1,115 @@
+package geofence_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/geofence"
+	"github.com/lambertmata/gortree/geom"
+)
+
+var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newGeofencer(t *testing.T, opts geofence.Options) (*gortree.RTree, *geofence.Geofencer) {
+	t.Helper()
+
+	rt := gortree.NewRTree()
+
+	// A triangle, whose bounding box isn't its shape
+	rt.Insert(&geofence.Fence{
+		Name:    "triangle",
+		Polygon: geom.Polygon{{{0, 0}, {10, 0}, {0, 10}, {0, 0}}},
+	})
+
+	rt.Insert(&geofence.Fence{
+		Name:    "square",
+		Polygon: geom.Polygon{{{5, 0}, {15, 0}, {15, 10}, {5, 10}, {5, 0}}},
+	})
+
+	return rt, geofence.New(rt, opts)
+}
+
+type expectedEvent struct {
+	Kind    geofence.EventKind
+	FenceID string
+}
+
+func checkEvents(t *testing.T, step string, events []geofence.Event, expected ...expectedEvent) {
+	t.Helper()
+
+	got := make([]expectedEvent, len(events))
+	for i, e := range events {
+		got[i] = expectedEvent{e.Kind, e.FenceID}
+	}
+
+	if !slices.Equal(got, expected) {
+		t.Errorf("%s: expected events %v, got %v", step, expected, got)
+	}
+}
+
+func TestGeofencer_Update(t *testing.T) {
+
+	_, g := newGeofencer(t, geofence.Options{})
+
+	checkEvents(t, "Outside", g.Update("dev", geom.Point{-5, -5}, start))
+
+	checkEvents(t, "Enter triangle", g.Update("dev", geom.Point{2, 2}, start),
+		expectedEvent{geofence.Enter, "triangle"})
+
+	checkEvents(t, "Still inside", g.Update("dev", geom.Point{3, 3}, start))
+
+	checkEvents(t, "Enter square", g.Update("dev", geom.Point{6, 2}, start),
+		expectedEvent{geofence.Enter, "square"})
+
+	// Inside the triangle's bounding box, but outside the triangle
+	checkEvents(t, "Exit triangle", g.Update("dev", geom.Point{8, 8}, start),
+		expectedEvent{geofence.Exit, "triangle"})
+
+	if inside := g.Inside("dev"); !slices.Equal(inside, []string{"square"}) {
+		t.Errorf("Expected device inside square, got %v", inside)
+	}
+
+	checkEvents(t, "Jump", g.Update("dev", geom.Point{1, 1}, start),
+		expectedEvent{geofence.Exit, "square"}, expectedEvent{geofence.Enter, "triangle"})
+
+	// Devices are tracked independently
+	checkEvents(t, "Other device", g.Update("other", geom.Point{1, 1}, start),
+		expectedEvent{geofence.Enter, "triangle"})
+}
+
+func TestGeofencer_Dwell(t *testing.T) {
+
+	_, g := newGeofencer(t, geofence.Options{Dwell: 10 * time.Minute})
+
+	checkEvents(t, "Enter", g.Update("dev", geom.Point{12, 5}, start),
+		expectedEvent{geofence.Enter, "square"})
+
+	checkEvents(t, "Too early", g.Update("dev", geom.Point{12, 5}, start.Add(5*time.Minute)))
+
+	checkEvents(t, "Dwell", g.Update("dev", geom.Point{12, 5}, start.Add(10*time.Minute)),
+		expectedEvent{geofence.Dwell, "square"})
+
+	checkEvents(t, "Dwell once", g.Update("dev", geom.Point{12, 5}, start.Add(20*time.Minute)))
+}
+
+func TestGeofencer_RemovedFence(t *testing.T) {
+
+	rt, g := newGeofencer(t, geofence.Options{})
+
+	g.Update("dev", geom.Point{12, 5}, start)
+
+	square := rt.Query(*gortree.NewRect(12, 5, 12, 5))[0]
+	if err := rt.Delete(square); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	checkEvents(t, "Fence removed", g.Update("dev", geom.Point{12, 5}, start),
+		expectedEvent{geofence.Exit, "square"})
+
+	g.Forget("dev")
+	if inside := g.Inside("dev"); len(inside) != 0 {
+		t.Errorf("Expected forgotten device to be nowhere, got %v", inside)
+	}
+}
@@ -0,0 +1,520 @@
+// Package geojson reads and writes GeoJSON feature collections, whose features can be indexed by a gortree.RTree.
+package geojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/geom"
+)
+
+// Feature is a GeoJSON feature. It implements gortree.Spatial, with the bounds of its geometry as bounding box. The
+// geometry of an unlocated feature, null in GeoJSON, is nil: such features are skipped by the readers and can't be
+// indexed.
+type Feature struct {
+	FeatureID  string
+	Geometry   geom.Geometry
+	Properties map[string]any
+}
+
+// ID returns the feature ID.
+func (f *Feature) ID() string {
+	return f.FeatureID
+}
+
+// BoundingBox returns the bounds of the geometry.
+func (f *Feature) BoundingBox() gortree.Rect {
+	return f.Geometry.Bounds()
+}
+
+// Geom returns the geometry, for exact refinement with geom.Intersecting.
+func (f *Feature) Geom() geom.Geometry {
+	return f.Geometry
+}
+
+// ContainsPoint tells whether the geometry contains the point, so that features can be used as fences.
+func (f *Feature) ContainsPoint(p geom.Point) bool {
+	return geom.Intersects(f.Geometry, p)
+}
+
+// rawFeature is the JSON representation of a feature.
+type rawFeature struct {
+	Type       string          `json:"type"`
+	ID         json.RawMessage `json:"id,omitempty"`
+	Geometry   json.RawMessage `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+// rawGeometry is the JSON representation of a geometry.
+type rawGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// UnmarshalJSON decodes a GeoJSON feature. The ID is taken from the feature id, either a string or a number.
+func (f *Feature) UnmarshalJSON(b []byte) error {
+
+	var raw rawFeature
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	if raw.Type != "Feature" {
+		return fmt.Errorf("expected type Feature, got %q", raw.Type)
+	}
+
+	id, err := decodeID(raw.ID)
+	if err != nil {
+		return err
+	}
+
+	geometry, err := decodeGeometry(raw.Geometry)
+	if err != nil {
+		return fmt.Errorf("feature %q: %w", id, err)
+	}
+
+	f.FeatureID = id
+	f.Geometry = geometry
+	f.Properties = raw.Properties
+
+	return nil
+}
+
+// MarshalJSON encodes the feature as GeoJSON.
+func (f *Feature) MarshalJSON() ([]byte, error) {
+
+	geometry, err := encodeGeometry(f.Geometry)
+	if err != nil {
+		return nil, fmt.Errorf("feature %q: %w", f.FeatureID, err)
+	}
+
+	id, err := json.Marshal(f.FeatureID)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(rawFeature{
+		Type:       "Feature",
+		ID:         id,
+		Geometry:   geometry,
+		Properties: f.Properties,
+	})
+}
+
+// decodeID returns the feature id as a string. Missing ids are returned empty.
+func decodeID(raw json.RawMessage) (string, error) {
+
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+
+	var id any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&id); err != nil {
+		return "", fmt.Errorf("invalid feature id: %w", err)
+	}
+
+	switch v := id.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("feature id must be a string or a number, got %s", raw)
+	}
+}
+
+// decodeGeometry decodes a GeoJSON geometry. Positions with an altitude are projected on the first two coordinates. A
+// null geometry is decoded as nil, and empty geometries, whose bounds are infinite, are rejected.
+func decodeGeometry(raw json.RawMessage) (geom.Geometry, error) {
+
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("missing geometry")
+	}
+
+	var g rawGeometry
+	if err := json.Unmarshal(raw, &g); err != nil {
+		return nil, fmt.Errorf("invalid geometry: %w", err)
+	}
+
+	var err error
+	var result geom.Geometry
+
+	switch g.Type {
+	case "Point":
+		var c []float64
+		if err = json.Unmarshal(g.Coordinates, &c); err == nil {
+			result, err = toPoint(c)
+		}
+	case "LineString":
+		var c [][]float64
+		if err = json.Unmarshal(g.Coordinates, &c); err == nil {
+			result, err = toLineString(c)
+		}
+	case "Polygon":
+		var c [][][]float64
+		if err = json.Unmarshal(g.Coordinates, &c); err == nil {
+			result, err = toPolygon(c)
+		}
+	case "MultiPoint":
+		var c [][]float64
+		if err = json.Unmarshal(g.Coordinates, &c); err == nil {
+			var l geom.LineString
+			l, err = toLineString(c)
+			result = geom.MultiPoint(l)
+		}
+	case "MultiLineString":
+		var c [][][]float64
+		if err = json.Unmarshal(g.Coordinates, &c); err == nil {
+			var p geom.Polygon
+			p, err = toPolygon(c)
+			result = geom.MultiLineString(p)
+		}
+	case "MultiPolygon":
+		var c [][][][]float64
+		if err = json.Unmarshal(g.Coordinates, &c); err == nil {
+			m := make(geom.MultiPolygon, len(c))
+			for i := range c {
+				if m[i], err = toPolygon(c[i]); err != nil {
+					break
+				}
+			}
+			result = m
+		}
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", g.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s coordinates: %w", g.Type, err)
+	}
+
+	b := result.Bounds()
+	for _, v := range []float64{b.MinX, b.MinY, b.MaxX, b.MaxY} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("empty %s", g.Type)
+		}
+	}
+
+	return result, nil
+}
+
+func toPoint(c []float64) (geom.Point, error) {
+	if len(c) < 2 {
+		return geom.Point{}, fmt.Errorf("position must have at least 2 coordinates, got %d", len(c))
+	}
+	return geom.Point{c[0], c[1]}, nil
+}
+
+func toLineString(c [][]float64) (geom.LineString, error) {
+	l := make(geom.LineString, len(c))
+	for i := range c {
+		p, err := toPoint(c[i])
+		if err != nil {
+			return nil, err
+		}
+		l[i] = p
+	}
+	return l, nil
+}
+
+func toPolygon(c [][][]float64) (geom.Polygon, error) {
+	p := make(geom.Polygon, len(c))
+	for i := range c {
+		l, err := toLineString(c[i])
+		if err != nil {
+			return nil, err
+		}
+		p[i] = l
+	}
+	return p, nil
+}
+
+// encodeGeometry encodes a geometry as GeoJSON, nil as null.
+func encodeGeometry(g geom.Geometry) (json.RawMessage, error) {
+
+	var typ string
+
+	switch g.(type) {
+	case nil:
+		return json.RawMessage("null"), nil
+	case geom.Point:
+		typ = "Point"
+	case geom.LineString:
+		typ = "LineString"
+	case geom.Polygon:
+		typ = "Polygon"
+	case geom.MultiPoint:
+		typ = "MultiPoint"
+	case geom.MultiLineString:
+		typ = "MultiLineString"
+	case geom.MultiPolygon:
+		typ = "MultiPolygon"
+	default:
+		return nil, fmt.Errorf("unsupported geometry %T", g)
+	}
+
+	// The geometries are nested slices of points, which encode as nested arrays of positions
+	coordinates, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(rawGeometry{Type: typ, Coordinates: coordinates})
+}
+
+// Options configures how features are read.
+type Options struct {
+	// IDProperty, when set, is the property holding the feature ID in place of the feature id.
+	IDProperty string
+
+	// Unlocated, when set, is called with the features whose geometry is null, which are skipped.
+	Unlocated func(*Feature)
+}
+
+// skip tells whether the feature is unlocated, reporting it according to the options.
+func (o Options) skip(f *Feature) bool {
+
+	if f.Geometry != nil {
+		return false
+	}
+
+	if o.Unlocated != nil {
+		o.Unlocated(f)
+	}
+
+	return true
+}
+
+// featureID returns the ID of the feature according to the options.
+func (o Options) featureID(f *Feature) (string, error) {
+
+	if o.IDProperty == "" {
+		if f.FeatureID == "" {
+			return "", errors.New("feature has no id")
+		}
+		return f.FeatureID, nil
+	}
+
+	switch v := f.Properties[o.IDProperty].(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case nil:
+		return "", fmt.Errorf("feature has no %q property", o.IDProperty)
+	default:
+		return "", fmt.Errorf("property %q must be a string or a number, got %T", o.IDProperty, v)
+	}
+}
+
+// ReadFeatureCollection decodes a FeatureCollection from r, calling fn with each feature as soon as it has been read,
+// so that large collections are never held in memory. Members other than features, and unlocated features, are skipped.
+func ReadFeatureCollection(r io.Reader, opts Options, fn func(*Feature) error) error {
+
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	typ := ""
+
+	for dec.More() {
+
+		key, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("read feature collection: %w", err)
+		}
+
+		switch key {
+
+		case "type":
+			if err := dec.Decode(&typ); err != nil {
+				return fmt.Errorf("read feature collection type: %w", err)
+			}
+			if typ != "FeatureCollection" {
+				return fmt.Errorf("expected type FeatureCollection, got %q", typ)
+			}
+
+		case "features":
+			if err := expectDelim(dec, '['); err != nil {
+				return err
+			}
+
+			for i := 0; dec.More(); i++ {
+
+				f := &Feature{}
+				if err := dec.Decode(f); err != nil {
+					return fmt.Errorf("read feature %d: %w", i, err)
+				}
+
+				if opts.skip(f) {
+					continue
+				}
+
+				if f.FeatureID, err = opts.featureID(f); err != nil {
+					return fmt.Errorf("read feature %d: %w", i, err)
+				}
+
+				if err := fn(f); err != nil {
+					return err
+				}
+			}
+
+			if err := expectDelim(dec, ']'); err != nil {
+				return err
+			}
+
+		default:
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return fmt.Errorf("read feature collection: %w", err)
+			}
+		}
+	}
+
+	if typ == "" {
+		return errors.New("missing feature collection type")
+	}
+
+	return expectDelim(dec, '}')
+}
+
+// expectDelim reads the next token, failing if it isn't the delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("read feature collection: %w", err)
+	}
+	if tok != delim {
+		return fmt.Errorf("read feature collection: expected %q, got %v", delim, tok)
+	}
+	return nil
+}
+
+// ReadFeatureSequence decodes a sequence of features from r, such as newline-delimited GeoJSON with one feature per
+// line, calling fn with each feature as soon as it has been read. Unlocated features are skipped.
+func ReadFeatureSequence(r io.Reader, opts Options, fn func(*Feature) error) error {
+
+	dec := json.NewDecoder(r)
+
+	for i := 0; ; i++ {
+
+		f := &Feature{}
+		if err := dec.Decode(f); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("read feature %d: %w", i, err)
+		}
+
+		if opts.skip(f) {
+			continue
+		}
+
+		var err error
+		if f.FeatureID, err = opts.featureID(f); err != nil {
+			return fmt.Errorf("read feature %d: %w", i, err)
+		}
+
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+}
+
+// loadBatchSize is the number of features inserted into the tree at once by Load.
+const loadBatchSize = 1024
+
+// Load reads a FeatureCollection from r into the tree and returns the number of features inserted.
+func Load(r io.Reader, rt *gortree.RTree, opts Options) (int, error) {
+
+	count := 0
+	batch := make([]gortree.Spatial, 0, loadBatchSize)
+
+	flush := func() {
+		rt.InsertBatch(batch)
+		count += len(batch)
+		batch = batch[:0]
+	}
+
+	err := ReadFeatureCollection(r, opts, func(f *Feature) error {
+		batch = append(batch, f)
+		if len(batch) == loadBatchSize {
+			flush()
+		}
+		return nil
+	})
+
+	flush()
+
+	return count, err
+}
+
+// WriteFeatureCollection encodes the items as a FeatureCollection, such as the results of a query. Items other than
+// features are written as the geometry of their bounding box.
+func WriteFeatureCollection(w io.Writer, items []gortree.Spatial) error {
+
+	if _, err := io.WriteString(w, `{"type":"FeatureCollection","features":[`); err != nil {
+		return err
+	}
+
+	for i, item := range items {
+
+		b, err := json.Marshal(FromSpatial(item))
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "]}\n")
+	return err
+}
+
+// FromSpatial returns the item when it is a feature, or a feature with the ID of the item and the geometry of its
+// bounding box otherwise, so that any entry of a tree can be written as GeoJSON.
+func FromSpatial(item gortree.Spatial) *Feature {
+	if f, ok := item.(*Feature); ok {
+		return f
+	}
+	return &Feature{FeatureID: item.ID(), Geometry: geom.FromRect(item.BoundingBox())}
+}
+
+// Codec persists features as GeoJSON with gortree.WriteSnapshot and gortree.DurableRTree. Entries other than features
+// are encoded as the geometry of their bounding box, and decoded as features.
+type Codec struct{}
+
+// Encode encodes the entry as a GeoJSON feature.
+func (Codec) Encode(data gortree.Spatial) ([]byte, error) {
+	return json.Marshal(FromSpatial(data))
+}
+
+// Decode decodes a GeoJSON feature.
+func (Codec) Decode(b []byte) (gortree.Spatial, error) {
+	f := &Feature{}
+	if err := json.Unmarshal(b, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
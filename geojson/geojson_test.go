@@ -0,0 +1,287 @@
+package geojson_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/geojson"
+	"github.com/lambertmata/gortree/geom"
+)
+
+const collection = `{
+  "type": "FeatureCollection",
+  "name": "sample",
+  "features": [
+    {"type": "Feature", "id": "point", "properties": {"code": "P"},
+     "geometry": {"type": "Point", "coordinates": [1, 2, 100]}},
+    {"type": "Feature", "id": 42, "properties": {"code": "L"},
+     "geometry": {"type": "LineString", "coordinates": [[0, 0], [10, 5]]}},
+    {"type": "Feature", "id": "polygon", "properties": {"code": "PG"},
+     "geometry": {"type": "Polygon", "coordinates": [[[20, 20], [30, 20], [30, 30], [20, 20]]]}},
+    {"type": "Feature", "id": "multipoint", "properties": {"code": "MP"},
+     "geometry": {"type": "MultiPoint", "coordinates": [[-5, -5], [-4, -3]]}},
+    {"type": "Feature", "id": "multilinestring", "properties": {"code": "ML"},
+     "geometry": {"type": "MultiLineString", "coordinates": [[[50, 50], [51, 51]], [[52, 52], [53, 55]]]}},
+    {"type": "Feature", "id": "multipolygon", "properties": {"code": "MPG"},
+     "geometry": {"type": "MultiPolygon", "coordinates": [[[[60, 60], [61, 60], [61, 61], [60, 60]]], [[[70, 70], [71, 70], [71, 71], [70, 70]]]]}}
+  ]
+}`
+
+func TestLoad(t *testing.T) {
+
+	rt := gortree.NewRTree()
+
+	count, err := geojson.Load(strings.NewReader(collection), rt, geojson.Options{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if count != 6 {
+		t.Errorf("Expected 6 features, got %d", count)
+	}
+
+	testCases := []struct {
+		Name     string
+		Rect     gortree.Rect
+		Expected []string
+	}{
+		{"Point", *gortree.NewRect(1, 2, 1, 2), []string{"42", "point"}},
+		{"Multipolygon bounds", *gortree.NewRect(65, 65, 66, 66), []string{"multipolygon"}},
+		{"Negative", *gortree.NewRect(-10, -10, -1, -1), []string{"multipoint"}},
+		{"Multilinestring", *gortree.NewRect(53, 55, 53, 55), []string{"multilinestring"}},
+	}
+
+	for _, testCase := range testCases {
+		var got []string
+		for _, item := range rt.Query(testCase.Rect) {
+			got = append(got, item.ID())
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, testCase.Expected) {
+			t.Errorf("Expected %v in %s, got %v", testCase.Expected, testCase.Name, got)
+		}
+	}
+}
+
+func TestLoad_IDProperty(t *testing.T) {
+
+	rt := gortree.NewRTree()
+
+	if _, err := geojson.Load(strings.NewReader(collection), rt, geojson.Options{IDProperty: "code"}); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	res := rt.Query(*gortree.NewRect(20, 20, 30, 30))
+	if len(res) != 1 || res[0].ID() != "PG" {
+		t.Errorf("Expected feature PG, got %v", res)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+
+	testCases := []struct {
+		Name  string
+		Input string
+	}{
+		{"Not a collection", `{"type": "Feature", "features": []}`},
+		{"Missing id", `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Point", "coordinates": [0, 0]}}]}`},
+		{"Unsupported geometry", `{"type": "FeatureCollection", "features": [{"type": "Feature", "id": "a", "geometry": {"type": "Circle", "coordinates": [0, 0]}}]}`},
+		{"Bad coordinates", `{"type": "FeatureCollection", "features": [{"type": "Feature", "id": "a", "geometry": {"type": "Point", "coordinates": [0]}}]}`},
+		{"Empty coordinates", `{"type": "FeatureCollection", "features": [{"type": "Feature", "id": "a", "geometry": {"type": "LineString", "coordinates": []}}]}`},
+		{"Empty rings", `{"type": "FeatureCollection", "features": [{"type": "Feature", "id": "a", "geometry": {"type": "MultiPolygon", "coordinates": [[[]]]}}]}`},
+		{"Missing type", `{"features": []}`},
+		{"Truncated", `{"type": "FeatureCollection", "features": [`},
+	}
+
+	for _, testCase := range testCases {
+		if _, err := geojson.Load(strings.NewReader(testCase.Input), gortree.NewRTree(), geojson.Options{}); err == nil {
+			t.Errorf("Expected error for %s", testCase.Name)
+		}
+	}
+}
+
+func TestLoad_Unlocated(t *testing.T) {
+
+	input := `{"type": "FeatureCollection", "features": [
+	  {"type": "Feature", "id": "a", "geometry": {"type": "Point", "coordinates": [1, 2]}},
+	  {"type": "Feature", "id": "unlocated", "geometry": null},
+	  {"type": "Feature", "geometry": null},
+	  {"type": "Feature", "id": "b", "geometry": {"type": "Point", "coordinates": [3, 4]}}
+	]}`
+
+	var unlocated []string
+	opts := geojson.Options{Unlocated: func(f *geojson.Feature) { unlocated = append(unlocated, f.ID()) }}
+
+	rt := gortree.NewRTree()
+	count, err := geojson.Load(strings.NewReader(input), rt, opts)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if count != 2 || rt.Len() != 2 {
+		t.Errorf("Expected 2 features, got %d with %d entries", count, rt.Len())
+	}
+	if !slices.Equal(unlocated, []string{"unlocated", ""}) {
+		t.Errorf("Expected unlocated feature reported, got %v", unlocated)
+	}
+
+	// Without the option, unlocated features are skipped silently, even without an id
+	sequence := `{"type": "Feature", "id": "a", "geometry": null}
+{"type": "Feature", "geometry": null}
+{"type": "Feature", "id": "b", "geometry": {"type": "Point", "coordinates": [3, 4]}}
+`
+	var got []string
+	err = geojson.ReadFeatureSequence(strings.NewReader(sequence), geojson.Options{}, func(f *geojson.Feature) error {
+		got = append(got, f.ID())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadFeatureSequence: %v", err)
+	}
+	if !slices.Equal(got, []string{"b"}) {
+		t.Errorf("Expected [b], got %v", got)
+	}
+
+	// Unlocated features round-trip with a null geometry
+	f := &geojson.Feature{}
+	if err := json.Unmarshal([]byte(`{"type": "Feature", "id": "a", "geometry": null}`), f); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.Contains(string(b), `"geometry":null`) {
+		t.Errorf("Expected a null geometry, got %s", b)
+	}
+}
+
+type Marker struct {
+	Name string
+}
+
+func (m *Marker) ID() string {
+	return m.Name
+}
+
+func (m *Marker) BoundingBox() gortree.Rect {
+	return *gortree.NewRect(0, 0, 1, 1)
+}
+
+func TestFromSpatial(t *testing.T) {
+
+	f := &geojson.Feature{FeatureID: "a", Geometry: geom.Point{1, 2}}
+	if got := geojson.FromSpatial(f); got != f {
+		t.Errorf("Expected the feature itself, got %v", got)
+	}
+
+	got := geojson.FromSpatial(&Marker{"marker"})
+	if got.ID() != "marker" || got.BoundingBox() != *gortree.NewRect(0, 0, 1, 1) {
+		t.Errorf("Expected marker with its bounding box, got %s %v", got.ID(), got.BoundingBox())
+	}
+}
+
+func TestWriteFeatureCollection(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	if _, err := geojson.Load(strings.NewReader(collection), rt, geojson.Options{}); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	rt.Insert(&Marker{"marker"})
+
+	var buf bytes.Buffer
+	if err := geojson.WriteFeatureCollection(&buf, rt.Query(*gortree.NewRect(-180, -90, 180, 90))); err != nil {
+		t.Fatalf("WriteFeatureCollection: %v", err)
+	}
+
+	if !json.Valid(buf.Bytes()) {
+		t.Fatalf("Expected valid JSON, got %s", buf.String())
+	}
+
+	// The output can be read back
+	var features []*geojson.Feature
+	err := geojson.ReadFeatureCollection(&buf, geojson.Options{}, func(f *geojson.Feature) error {
+		features = append(features, f)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadFeatureCollection: %v", err)
+	}
+
+	if len(features) != 7 {
+		t.Fatalf("Expected 7 features, got %d", len(features))
+	}
+
+	for _, f := range features {
+		switch f.ID() {
+		case "marker":
+			if _, ok := f.Geometry.(geom.Polygon); !ok || f.BoundingBox() != *gortree.NewRect(0, 0, 1, 1) {
+				t.Errorf("Expected marker written as its bounding box, got %v", f.Geometry)
+			}
+		case "multipolygon":
+			if _, ok := f.Geometry.(geom.MultiPolygon); !ok || f.Properties["code"] != "MPG" {
+				t.Errorf("Expected multipolygon with its properties, got %v %v", f.Geometry, f.Properties)
+			}
+		}
+	}
+}
+
+func TestReadFeatureSequence(t *testing.T) {
+
+	input := `{"type": "Feature", "id": "a", "properties": {"code": "A"}, "geometry": {"type": "Point", "coordinates": [1, 2]}}
+
+{"type": "Feature", "id": "b", "properties": {"code": "B"}, "geometry": {"type": "Point", "coordinates": [3, 4]}}
+`
+
+	var got []string
+	err := geojson.ReadFeatureSequence(strings.NewReader(input), geojson.Options{IDProperty: "code"}, func(f *geojson.Feature) error {
+		got = append(got, f.ID())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadFeatureSequence: %v", err)
+	}
+
+	if !slices.Equal(got, []string{"A", "B"}) {
+		t.Errorf("Expected A and B, got %v", got)
+	}
+
+	truncated := `{"type": "Feature", "id": "a", "geometry": {"type": "Point", "coordinates": [1, 2]}}
+{"type": "Feature", "id": "b", "geom`
+	err = geojson.ReadFeatureSequence(strings.NewReader(truncated), geojson.Options{}, func(*geojson.Feature) error {
+		return nil
+	})
+	if err == nil {
+		t.Errorf("Expected error for truncated input")
+	}
+}
+
+func TestCodec(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	if _, err := geojson.Load(strings.NewReader(collection), rt, geojson.Options{}); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	rt.Insert(&Marker{"marker"})
+
+	var buf bytes.Buffer
+	if err := rt.WriteSnapshot(&buf, geojson.Codec{}); err != nil {
+		t.Fatalf("WriteSnapshot: %v", err)
+	}
+
+	restored := gortree.NewRTree()
+	if err := restored.ReadSnapshot(&buf, geojson.Codec{}); err != nil {
+		t.Fatalf("ReadSnapshot: %v", err)
+	}
+
+	if restored.Len() != 7 {
+		t.Fatalf("Expected 7 entries, got %d", restored.Len())
+	}
+
+	res := restored.Query(*gortree.NewRect(60.5, 60.5, 60.5, 60.5))
+	if len(res) != 1 || res[0].(*geojson.Feature).Properties["code"] != "MPG" {
+		t.Errorf("Expected multipolygon with its properties, got %v", res)
+	}
+}
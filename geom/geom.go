@@ -0,0 +1,197 @@
+// Package geom provides planar geometries that can be indexed by a gortree.RTree, along with the exact predicates
+// used to refine the candidates found through their bounding boxes.
+package geom
+
+import (
+	"math"
+
+	"github.com/lambertmata/gortree"
+)
+
+// Geometry is a shape with a bounding box.
+type Geometry interface {
+	Bounds() gortree.Rect
+}
+
+// Point is a position, as x and y or longitude and latitude.
+type Point [2]float64
+
+// LineString is a sequence of points connected by straight segments. Closed line strings are used as polygon rings.
+type LineString []Point
+
+// Polygon is an area delimited by an exterior ring, the first, and optional interior rings for holes.
+type Polygon []LineString
+
+// MultiPoint is a collection of points.
+type MultiPoint []Point
+
+// MultiLineString is a collection of line strings.
+type MultiLineString []LineString
+
+// MultiPolygon is a collection of polygons.
+type MultiPolygon []Polygon
+
+// GeometryCollection is a collection of geometries of any type.
+type GeometryCollection []Geometry
+
+// Shape is a geometry with an ID, so that it can be stored in a gortree.RTree.
+type Shape struct {
+	Name     string
+	Geometry Geometry
+}
+
+// Geometric is implemented by entries with an exact geometry, used to refine the candidates found through their
+// bounding box.
+type Geometric interface {
+	gortree.Spatial
+	Geom() Geometry
+}
+
+// emptyBounds is the starting point to compute bounds by expansion.
+var emptyBounds = gortree.Rect{
+	MinX: math.Inf(1), MinY: math.Inf(1),
+	MaxX: math.Inf(-1), MaxY: math.Inf(-1),
+}
+
+// Bounds returns the point itself as a degenerate rect.
+func (p Point) Bounds() gortree.Rect {
+	return gortree.Rect{MinX: p[0], MinY: p[1], MaxX: p[0], MaxY: p[1]}
+}
+
+// Bounds returns the rect containing all the points.
+func (l LineString) Bounds() gortree.Rect {
+	bounds := emptyBounds
+	for _, p := range l {
+		bounds.Expand(p.Bounds())
+	}
+	return bounds
+}
+
+// Bounds returns the bounds of the exterior ring.
+func (p Polygon) Bounds() gortree.Rect {
+	if len(p) == 0 {
+		return emptyBounds
+	}
+	return p[0].Bounds()
+}
+
+// Bounds returns the rect containing all the points.
+func (m MultiPoint) Bounds() gortree.Rect {
+	return LineString(m).Bounds()
+}
+
+// Bounds returns the rect containing all the line strings.
+func (m MultiLineString) Bounds() gortree.Rect {
+	bounds := emptyBounds
+	for _, l := range m {
+		bounds.Expand(l.Bounds())
+	}
+	return bounds
+}
+
+// Bounds returns the rect containing all the polygons.
+func (m MultiPolygon) Bounds() gortree.Rect {
+	bounds := emptyBounds
+	for _, p := range m {
+		bounds.Expand(p.Bounds())
+	}
+	return bounds
+}
+
+// Bounds returns the rect containing all the geometries.
+func (c GeometryCollection) Bounds() gortree.Rect {
+	bounds := emptyBounds
+	for _, g := range c {
+		bounds.Expand(g.Bounds())
+	}
+	return bounds
+}
+
+// ID returns the shape name.
+func (s *Shape) ID() string {
+	return s.Name
+}
+
+// BoundingBox returns the bounds of the geometry.
+func (s *Shape) BoundingBox() gortree.Rect {
+	return s.Geometry.Bounds()
+}
+
+// Geom returns the geometry.
+func (s *Shape) Geom() Geometry {
+	return s.Geometry
+}
+
+// ContainsPoint tells whether the geometry contains the point, so that shapes can be used as fences.
+func (s *Shape) ContainsPoint(p Point) bool {
+	return Intersects(s.Geometry, p)
+}
+
+// IsEmpty tells whether the geometry has no point, such as an empty line string or collection. Its bounds are infinite,
+// so it can't be inserted into a gortree.RTree.
+func IsEmpty(g Geometry) bool {
+	b := g.Bounds()
+	return b.MinX > b.MaxX || b.MinY > b.MaxY
+}
+
+// FromRect returns the geometry covering the rect: a point or a line string when it is degenerate, a polygon
+// otherwise.
+func FromRect(r gortree.Rect) Geometry {
+	switch {
+	case r.MinX == r.MaxX && r.MinY == r.MaxY:
+		return Point{r.MinX, r.MinY}
+	case r.MinX == r.MaxX || r.MinY == r.MaxY:
+		return LineString{{r.MinX, r.MinY}, {r.MaxX, r.MaxY}}
+	default:
+		return Polygon{{{r.MinX, r.MinY}, {r.MaxX, r.MinY}, {r.MaxX, r.MaxY}, {r.MinX, r.MaxY}, {r.MinX, r.MinY}}}
+	}
+}
+
+// ContainsPoint tells whether the point lies inside the polygon, holes excluded. Points on the boundary may be
+// reported either way.
+func (p Polygon) ContainsPoint(pt Point) bool {
+
+	if len(p) == 0 || !ringContains(p[0], pt) {
+		return false
+	}
+
+	for _, hole := range p[1:] {
+		if ringContains(hole, pt) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ContainsPoint tells whether the point lies inside any of the polygons.
+func (m MultiPolygon) ContainsPoint(pt Point) bool {
+	for _, p := range m {
+		if p.ContainsPoint(pt) {
+			return true
+		}
+	}
+	return false
+}
+
+// ringContains tells whether the point lies inside the ring using the even-odd rule. The ring may be closed or not.
+func ringContains(ring LineString, pt Point) bool {
+
+	inside := false
+	n := len(ring)
+
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+
+		a, b := ring[i], ring[j]
+
+		// Count the edges crossed by a ray going right from the point
+		if (a[1] > pt[1]) != (b[1] > pt[1]) {
+			x := a[0] + (pt[1]-a[1])*(b[0]-a[0])/(b[1]-a[1])
+			if pt[0] < x {
+				inside = !inside
+			}
+		}
+	}
+
+	return inside
+}
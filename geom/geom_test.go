@@ -0,0 +1,127 @@
+package geom_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/geom"
+)
+
+// square with a square hole in the middle
+var frame = geom.Polygon{
+	{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+	{{4, 4}, {6, 4}, {6, 6}, {4, 6}, {4, 4}},
+}
+
+func TestPolygon_Bounds(t *testing.T) {
+	if b := frame.Bounds(); b != *gortree.NewRect(0, 0, 10, 10) {
+		t.Errorf("Expected (0,0,10,10), got %v", b)
+	}
+}
+
+func TestPolygon_ContainsPoint(t *testing.T) {
+
+	testCases := []struct {
+		Name     string
+		Point    geom.Point
+		Expected bool
+	}{
+		{"Inside", geom.Point{2, 2}, true},
+		{"Hole", geom.Point{5, 5}, false},
+		{"Outside", geom.Point{11, 5}, false},
+		{"Outside, aligned with edge", geom.Point{-1, 0}, false},
+	}
+
+	for _, testCase := range testCases {
+		if got := frame.ContainsPoint(testCase.Point); got != testCase.Expected {
+			t.Errorf("Expected %v for %s, got %v", testCase.Expected, testCase.Name, got)
+		}
+	}
+
+	// Triangle, where the bounding box isn't enough
+	triangle := geom.Polygon{{{0, 0}, {10, 0}, {0, 10}}}
+	if triangle.ContainsPoint(geom.Point{8, 8}) {
+		t.Errorf("Expected point outside of the triangle")
+	}
+}
+
+func TestBounds(t *testing.T) {
+
+	testCases := []struct {
+		Name     string
+		Geometry geom.Geometry
+		Expected gortree.Rect
+	}{
+		{"Point", geom.Point{1, 2}, *gortree.NewRect(1, 2, 1, 2)},
+		{"LineString", geom.LineString{{0, 5}, {3, -1}}, *gortree.NewRect(0, -1, 3, 5)},
+		{"MultiPoint", geom.MultiPoint{{-1, 0}, {1, 1}}, *gortree.NewRect(-1, 0, 1, 1)},
+		{"MultiLineString", geom.MultiLineString{{{0, 0}, {1, 1}}, {{5, 5}, {6, 7}}}, *gortree.NewRect(0, 0, 6, 7)},
+		{"MultiPolygon", geom.MultiPolygon{frame, {{{20, 20}, {30, 20}, {30, 30}, {20, 20}}}}, *gortree.NewRect(0, 0, 30, 30)},
+	}
+
+	for _, testCase := range testCases {
+		if got := testCase.Geometry.Bounds(); got != testCase.Expected {
+			t.Errorf("Expected %v for %s, got %v", testCase.Expected, testCase.Name, got)
+		}
+	}
+}
+
+func TestIntersects(t *testing.T) {
+
+	triangle := geom.Polygon{{{0, 0}, {10, 0}, {0, 10}, {0, 0}}}
+
+	testCases := []struct {
+		Name     string
+		A, B     geom.Geometry
+		Expected bool
+	}{
+		{"Point in polygon", triangle, geom.Point{2, 2}, true},
+		{"Point on boundary", triangle, geom.Point{5, 0}, true},
+		{"Point outside, within bounds", triangle, geom.Point{8, 8}, false},
+		{"Point in hole", frame, geom.Point{5, 5}, false},
+		{"Crossing lines", geom.LineString{{0, 0}, {10, 10}}, geom.LineString{{0, 10}, {10, 0}}, true},
+		{"Parallel lines", geom.LineString{{0, 0}, {10, 0}}, geom.LineString{{0, 1}, {10, 1}}, false},
+		{"Line inside polygon", triangle, geom.LineString{{1, 1}, {2, 2}}, true},
+		{"Line crossing polygon", triangle, geom.LineString{{-1, 1}, {11, 1}}, true},
+		{"Line past polygon", triangle, geom.LineString{{6, 6}, {9, 9}}, false},
+		{"Polygon inside hole", frame, geom.Polygon{{{4.5, 4.5}, {5.5, 4.5}, {5, 5.5}, {4.5, 4.5}}}, false},
+		{"Nested polygons", frame, geom.Polygon{{{1, 1}, {2, 1}, {2, 2}, {1, 1}}}, true},
+		{"Overlapping polygons", triangle, geom.FromRect(*gortree.NewRect(4, 4, 20, 20)), true},
+		{"Collection", geom.GeometryCollection{geom.Point{50, 50}, geom.Point{1, 1}}, triangle, true},
+	}
+
+	for _, testCase := range testCases {
+		if got := geom.Intersects(testCase.A, testCase.B); got != testCase.Expected {
+			t.Errorf("Expected %v for %s, got %v", testCase.Expected, testCase.Name, got)
+		}
+		if got := geom.Intersects(testCase.B, testCase.A); got != testCase.Expected {
+			t.Errorf("Expected %v for %s reversed, got %v", testCase.Expected, testCase.Name, got)
+		}
+	}
+}
+
+func TestIntersecting(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	rt.Insert(&geom.Shape{Name: "inside", Geometry: geom.Point{1, 1}})
+	rt.Insert(&geom.Shape{Name: "corner", Geometry: geom.Point{8, 8}})
+	rt.Insert(&geom.Shape{Name: "road", Geometry: geom.LineString{{-5, 2}, {-1, 2}, {3, 2}}})
+
+	triangle := geom.Polygon{{{0, 0}, {10, 0}, {0, 10}, {0, 0}}}
+
+	// The bounding box query also returns the corner
+	if got := len(rt.Query(triangle.Bounds())); got != 3 {
+		t.Errorf("Expected 3 candidates, got %d", got)
+	}
+
+	var got []string
+	for _, item := range geom.Intersecting(rt, triangle) {
+		got = append(got, item.ID())
+	}
+	slices.Sort(got)
+
+	if !slices.Equal(got, []string{"inside", "road"}) {
+		t.Errorf("Expected inside and road, got %v", got)
+	}
+}
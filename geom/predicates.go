@@ -0,0 +1,248 @@
+package geom
+
+import (
+	"github.com/lambertmata/gortree"
+)
+
+// parts holds the simple components of a geometry.
+type parts struct {
+	points   []Point
+	lines    []LineString
+	polygons []Polygon
+}
+
+// decompose adds the simple components of g to p.
+func (p *parts) decompose(g Geometry) {
+	switch g := g.(type) {
+	case Point:
+		p.points = append(p.points, g)
+	case LineString:
+		p.lines = append(p.lines, g)
+	case Polygon:
+		p.polygons = append(p.polygons, g)
+	case MultiPoint:
+		p.points = append(p.points, g...)
+	case MultiLineString:
+		p.lines = append(p.lines, g...)
+	case MultiPolygon:
+		p.polygons = append(p.polygons, g...)
+	case GeometryCollection:
+		for _, c := range g {
+			p.decompose(c)
+		}
+	}
+}
+
+// Intersects tells whether the two geometries share at least a point, boundaries included.
+func Intersects(a, b Geometry) bool {
+
+	ab, bb := a.Bounds(), b.Bounds()
+	if !ab.Intersects(bb) {
+		return false
+	}
+
+	var pa, pb parts
+	pa.decompose(a)
+	pb.decompose(b)
+
+	return pa.intersects(&pb) || pb.intersects(&pa)
+}
+
+// intersects tests the components of p against those of other. Pairs of different kinds are only tested in one
+// direction, Intersects calls it both ways.
+func (p *parts) intersects(other *parts) bool {
+
+	for _, pt := range p.points {
+		for _, o := range other.points {
+			if pt == o {
+				return true
+			}
+		}
+		for _, l := range other.lines {
+			if lineTouchesPoint(l, pt) {
+				return true
+			}
+		}
+		for _, poly := range other.polygons {
+			if polygonTouchesPoint(poly, pt) {
+				return true
+			}
+		}
+	}
+
+	for _, l := range p.lines {
+		for _, o := range other.lines {
+			if linesIntersect(l, o) {
+				return true
+			}
+		}
+		for _, poly := range other.polygons {
+			if polygonIntersectsLine(poly, l) {
+				return true
+			}
+		}
+	}
+
+	for _, poly := range p.polygons {
+		for _, o := range other.polygons {
+			if polygonsIntersect(poly, o) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// segments calls fn with each segment of the line string, and with the closing segment when closed is true. It stops
+// as soon as fn returns true, and returns whether it did.
+func segments(l LineString, closed bool, fn func(a, b Point) bool) bool {
+
+	if len(l) == 1 {
+		return fn(l[0], l[0])
+	}
+
+	for i := 1; i < len(l); i++ {
+		if fn(l[i-1], l[i]) {
+			return true
+		}
+	}
+
+	if closed && len(l) > 2 {
+		return fn(l[len(l)-1], l[0])
+	}
+
+	return false
+}
+
+// orientation returns the sign of the cross product of ab and ac: positive when c is to the left of ab.
+func orientation(a, b, c Point) int {
+	cross := (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+	switch {
+	case cross > 0:
+		return 1
+	case cross < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// onSegment tells whether c, collinear with ab, lies within the segment.
+func onSegment(a, b, c Point) bool {
+	return min(a[0], b[0]) <= c[0] && c[0] <= max(a[0], b[0]) &&
+		min(a[1], b[1]) <= c[1] && c[1] <= max(a[1], b[1])
+}
+
+// segmentsIntersect tells whether the segments pq and rs share a point.
+func segmentsIntersect(p, q, r, s Point) bool {
+
+	o1 := orientation(p, q, r)
+	o2 := orientation(p, q, s)
+	o3 := orientation(r, s, p)
+	o4 := orientation(r, s, q)
+
+	if o1 != o2 && o3 != o4 {
+		return true
+	}
+
+	return (o1 == 0 && onSegment(p, q, r)) ||
+		(o2 == 0 && onSegment(p, q, s)) ||
+		(o3 == 0 && onSegment(r, s, p)) ||
+		(o4 == 0 && onSegment(r, s, q))
+}
+
+// lineTouchesPoint tells whether the point lies on the line string.
+func lineTouchesPoint(l LineString, pt Point) bool {
+	return segments(l, false, func(a, b Point) bool {
+		return orientation(a, b, pt) == 0 && onSegment(a, b, pt)
+	})
+}
+
+// ringsTouchPoint tells whether the point lies on any ring of the polygon.
+func ringsTouchPoint(poly Polygon, pt Point) bool {
+	for _, ring := range poly {
+		if segments(ring, true, func(a, b Point) bool {
+			return orientation(a, b, pt) == 0 && onSegment(a, b, pt)
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// polygonTouchesPoint tells whether the point lies inside the polygon or on its boundary.
+func polygonTouchesPoint(poly Polygon, pt Point) bool {
+	return poly.ContainsPoint(pt) || ringsTouchPoint(poly, pt)
+}
+
+// linesIntersect tells whether the two line strings share a point.
+func linesIntersect(l, other LineString) bool {
+	return segments(l, false, func(a, b Point) bool {
+		return segments(other, false, func(c, d Point) bool {
+			return segmentsIntersect(a, b, c, d)
+		})
+	})
+}
+
+// ringsCross tells whether the line crosses or touches any ring of the polygon.
+func ringsCross(poly Polygon, l LineString, closed bool) bool {
+	for _, ring := range poly {
+		if segments(l, closed, func(a, b Point) bool {
+			return segments(ring, true, func(c, d Point) bool {
+				return segmentsIntersect(a, b, c, d)
+			})
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// polygonIntersectsLine tells whether the line string crosses the polygon boundary or lies inside it.
+func polygonIntersectsLine(poly Polygon, l LineString) bool {
+	if len(l) == 0 || len(poly) == 0 {
+		return false
+	}
+	return ringsCross(poly, l, false) || poly.ContainsPoint(l[0])
+}
+
+// polygonsIntersect tells whether the boundaries of the polygons cross, or one lies inside the other.
+func polygonsIntersect(poly, other Polygon) bool {
+
+	if len(poly) == 0 || len(other) == 0 || len(poly[0]) == 0 || len(other[0]) == 0 {
+		return false
+	}
+
+	for _, ring := range poly {
+		if ringsCross(other, ring, true) {
+			return true
+		}
+	}
+
+	return other.ContainsPoint(poly[0][0]) || poly.ContainsPoint(other[0][0])
+}
+
+// Intersecting finds all the entries of the tree intersecting the region exactly. Candidates are found with a query on
+// the region bounds, then refined against their geometry when they implement Geometric, or their bounding box
+// otherwise.
+func Intersecting(rt *gortree.RTree, region Geometry) []gortree.Spatial {
+
+	results := make([]gortree.Spatial, 0)
+
+	for _, candidate := range rt.Query(region.Bounds()) {
+
+		var g Geometry
+		if geometric, ok := candidate.(Geometric); ok {
+			g = geometric.Geom()
+		} else {
+			g = FromRect(candidate.BoundingBox())
+		}
+
+		if Intersects(region, g) {
+			results = append(results, candidate)
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,88 @@
+package gortree
+
+// GridCount bins the entries in r on a grid of cols × rows equal cells, for heatmaps. Each entry is counted in the
+// cell containing the center of its bounding box, entries whose center is outside r are ignored. The result is indexed
+// by [row][col], with row 0 at MinY and column 0 at MinX. Cells include their lower edges, the last row and column also
+// their upper ones. Nodes fully inside a single cell are counted from their stored subtree count instead of being
+// descended. GridCount returns nil when cols or rows is less than 1.
+func (t *RTree) GridCount(r Rect, cols, rows int) [][]int {
+
+	if cols < 1 || rows < 1 {
+		return nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	start := t.startQuery()
+	stats := QueryStats{Op: OpGridCount}
+
+	grid := make([][]int, rows)
+	for i := range grid {
+		grid[i] = make([]int, cols)
+	}
+
+	query := r.Box()
+	now := t.now()
+
+	col := func(x float64) int { return cell(x, r.MinX, r.MaxX, cols) }
+	row := func(y float64) int { return cell(y, r.MinY, r.MaxY, rows) }
+
+	stack := NewStackFrom(t.root)
+
+	for !stack.Empty() {
+
+		cur, _ := stack.Pop()
+		stats.NodesVisited++
+
+		if !cur.BoundingBox.Intersects(query) {
+			continue
+		}
+
+		b := cur.BoundingBox
+
+		// The subtree is entirely in one cell
+		if len(cur.Children) > 0 && query.Contains(b) && !expired(cur, now) {
+			c, rw := col(b.Min[0]), row(b.Min[1])
+			if c == col(b.Max[0]) && rw == row(b.Max[1]) {
+				grid[rw][c] += cur.Summary.Count
+				stats.Results += cur.Summary.Count
+				continue
+			}
+		}
+
+		if !cur.IsLeaf {
+			stack.Push(cur.Children...)
+			continue
+		}
+
+		stats.LeavesVisited++
+		stats.EntriesTested += len(cur.Children)
+
+		for _, e := range cur.Children {
+
+			x := (e.BoundingBox.Min[0] + e.BoundingBox.Max[0]) / 2
+			y := (e.BoundingBox.Min[1] + e.BoundingBox.Max[1]) / 2
+
+			if x < r.MinX || x > r.MaxX || y < r.MinY || y > r.MaxY || expired(e, now) {
+				continue
+			}
+
+			grid[row(y)][col(x)]++
+			stats.Results++
+		}
+	}
+
+	t.observeQuery(stats, start)
+
+	return grid
+}
+
+// cell returns the index of the cell containing v, among n equal cells spanning [lo, hi].
+func cell(v, lo, hi float64, n int) int {
+	if hi <= lo {
+		return 0
+	}
+	i := int((v - lo) / (hi - lo) * float64(n))
+	return max(0, min(i, n-1))
+}
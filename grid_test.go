@@ -0,0 +1,84 @@
+package gortree_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+)
+
+// bruteForceGrid bins the locations in r on a grid of cols × rows cells.
+func bruteForceGrid(locations []*Location, r gortree.Rect, cols, rows int) [][]int {
+
+	grid := make([][]int, rows)
+	for i := range grid {
+		grid[i] = make([]int, cols)
+	}
+
+	for _, l := range locations {
+		x, y := l.Coordinates[0], l.Coordinates[1]
+		if x < r.MinX || x > r.MaxX || y < r.MinY || y > r.MaxY {
+			continue
+		}
+		col := min(int((x-r.MinX)/(r.MaxX-r.MinX)*float64(cols)), cols-1)
+		row := min(int((y-r.MinY)/(r.MaxY-r.MinY)*float64(rows)), rows-1)
+		grid[row][col]++
+	}
+
+	return grid
+}
+
+func TestRTree_GridCount(t *testing.T) {
+
+	rec := &recorder{}
+	rt, err := gortree.NewRTreeWithOptions(gortree.WithMinMax(4, 16), gortree.WithObserver(rec))
+	if err != nil {
+		t.Fatalf("NewRTreeWithOptions: %v", err)
+	}
+
+	locations := randomLocations(5000, 11)
+	rt.InsertBatch(toSpatial(locations))
+
+	rnd := rand.New(rand.NewPCG(3, 4))
+
+	for i := 0; i < 30; i++ {
+
+		x, y := rnd.Float64()*360-180, rnd.Float64()*180-90
+		window := *gortree.NewRect(x, y, x+rnd.Float64()*120, y+rnd.Float64()*60)
+		cols, rows := 1+rnd.IntN(20), 1+rnd.IntN(20)
+
+		got := rt.GridCount(window, cols, rows)
+		want := bruteForceGrid(locations, window, cols, rows)
+
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Fatalf("GridCount(%v, %d, %d) = %v, want %v", window, cols, rows, got, want)
+		}
+	}
+
+	// On a coarse grid most leaves fall inside a single cell and aren't descended
+	rec.queries = nil
+	grid := rt.GridCount(*WholeWorld, 4, 2)
+
+	total := 0
+	for _, row := range grid {
+		for _, n := range row {
+			total += n
+		}
+	}
+	if total != len(locations) {
+		t.Errorf("GridCount(world) counts %d entries, want %d", total, len(locations))
+	}
+
+	stats := rec.queries[0]
+	if stats.Op != gortree.OpGridCount || stats.Results != len(locations) {
+		t.Errorf("stats = %+v, want %d results of %v", stats, len(locations), gortree.OpGridCount)
+	}
+	if stats.EntriesTested >= len(locations)/2 {
+		t.Errorf("GridCount(world) tested %d entries, want subtrees counted whole", stats.EntriesTested)
+	}
+
+	if grid := rt.GridCount(*WholeWorld, 0, 3); grid != nil {
+		t.Errorf("GridCount with 0 columns = %v, want nil", grid)
+	}
+}
@@ -0,0 +1,277 @@
+package gortree
+
+import (
+	"slices"
+)
+
+// hilbertOrder is the number of bits per axis of the grid mapped onto the Hilbert curve.
+const hilbertOrder = 16
+
+// hilbertValue returns the distance along the Hilbert curve of the box center, within the tree domain. Boxes with
+// more than two dimensions are ordered by their projection on the first two.
+func (t *RTree) hilbertValue(b Box) uint64 {
+
+	cx := (b.Min[0] + b.Max[0]) / 2
+	cy := (b.Min[1] + b.Max[1]) / 2
+
+	x := scaleToGrid(cx, t.domain.MinX, t.domain.MaxX)
+	y := scaleToGrid(cy, t.domain.MinY, t.domain.MaxY)
+
+	return hilbertXYToD(x, y)
+}
+
+// scaleToGrid maps v in [min, max] to a cell of the Hilbert grid, clamping values outside the range.
+func scaleToGrid(v, min, max float64) uint32 {
+
+	const cells = 1 << hilbertOrder
+
+	f := (v - min) / (max - min)
+	if !(f > 0) {
+		// Also catches NaN
+		return 0
+	}
+	if f >= 1 {
+		return cells - 1
+	}
+
+	return uint32(f * cells)
+}
+
+// hilbertXYToD converts grid coordinates to the distance along the Hilbert curve.
+func hilbertXYToD(x, y uint32) uint64 {
+
+	const n = 1 << hilbertOrder
+
+	var d uint64
+
+	for s := uint32(n / 2); s > 0; s /= 2 {
+
+		var rx, ry uint32
+		if x&s > 0 {
+			rx = 1
+		}
+		if y&s > 0 {
+			ry = 1
+		}
+
+		d += uint64(s) * uint64(s) * uint64((3*rx)^ry)
+
+		// Rotate the quadrant so that the curve is continuous
+		if ry == 0 {
+			if rx == 1 {
+				x = n - 1 - x
+				y = n - 1 - y
+			}
+			x, y = y, x
+		}
+	}
+
+	return d
+}
+
+// computeNodesLHV returns the largest Hilbert value among nodes.
+func computeNodesLHV(nodes []*node) uint64 {
+	var lhv uint64
+	for _, n := range nodes {
+		lhv = max(lhv, n.LHV)
+	}
+	return lhv
+}
+
+// insertHilbert adds the entry node to the leaf covering its Hilbert value, keeping leaves sorted. Requires t.mu held
+// for writing.
+func (t *RTree) insertHilbert(e *node) {
+
+	leaf := t.chooseLeafHilbert(t.root, e.LHV)
+
+	insertSorted(leaf, e)
+
+	t.handleOverflowHilbert(leaf)
+}
+
+// chooseLeafHilbert descends the tree following, at each level, the child with the smallest LHV greater than or
+// equal to h. If h is larger than every LHV the last child is followed.
+func (t *RTree) chooseLeafHilbert(n *node, h uint64) *node {
+
+	for !n.IsLeaf && len(n.Children) > 0 {
+
+		next := n.Children[len(n.Children)-1]
+
+		for _, c := range n.Children {
+			if c.LHV >= h {
+				next = c
+				break
+			}
+		}
+
+		n = next
+	}
+
+	return n
+}
+
+// insertSorted inserts child into n keeping n.Children sorted by LHV.
+func insertSorted(n *node, child *node) {
+
+	idx, _ := slices.BinarySearchFunc(n.Children, child.LHV, func(c *node, h uint64) int {
+		if c.LHV <= h {
+			return -1
+		}
+		return 1
+	})
+
+	n.Children = slices.Insert(n.Children, idx, child)
+	child.Parent = n
+}
+
+// handleOverflowHilbert resolves overflows starting from n and moving up the tree. An overflowing node first tries to
+// share its entries with a cooperating sibling. Only when the sibling is full as well, the two nodes are split into
+// three.
+func (t *RTree) handleOverflowHilbert(n *node) {
+
+	for t.nodeOverflowing(n) {
+
+		parent := n.Parent
+
+		// The root has no siblings, split it in two under a new root
+		if parent == nil {
+			t.splitRootHilbert(n)
+			return
+		}
+
+		sibling := t.cooperatingSibling(n)
+
+		// Without siblings the node is split in two
+		if sibling == nil {
+			t.redistributeHilbert(parent, []*node{n}, 2)
+			n = parent
+			continue
+		}
+
+		group := []*node{n, sibling}
+		if slices.Index(parent.Children, sibling) < slices.Index(parent.Children, n) {
+			group = []*node{sibling, n}
+		}
+
+		// The sibling has room, share the entries without splitting
+		if len(sibling.Children) < t.maxEntries {
+			t.redistributeHilbert(parent, group, 2)
+			break
+		}
+
+		// Both nodes are full: 2-to-3 split
+		t.redistributeHilbert(parent, group, 3)
+		n = parent
+	}
+
+	t.updateMBRsUpward(n)
+}
+
+// cooperatingSibling returns the sibling adjacent to n sharing its entries on overflow. The one with fewer entries is
+// preferred, the right one on a tie.
+func (t *RTree) cooperatingSibling(n *node) *node {
+
+	siblings := n.Parent.Children
+	idx := slices.Index(siblings, n)
+
+	var left, right *node
+	if idx > 0 {
+		left = siblings[idx-1]
+	}
+	if idx >= 0 && idx < len(siblings)-1 {
+		right = siblings[idx+1]
+	}
+
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case len(left.Children) < len(right.Children):
+		return left
+	default:
+		return right
+	}
+}
+
+// redistributeHilbert spreads the entries of the adjacent nodes in group evenly across count nodes, following the
+// Hilbert order. New nodes are added to the parent right after the group, which must be sorted by position in
+// parent.
+func (t *RTree) redistributeHilbert(parent *node, group []*node, count int) {
+
+	var entries []*node
+	for _, g := range group {
+		entries = append(entries, g.Children...)
+	}
+
+	slices.SortStableFunc(entries, func(a, b *node) int {
+		switch {
+		case a.LHV < b.LHV:
+			return -1
+		case a.LHV > b.LHV:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	nodes := slices.Clone(group)
+	insertAt := slices.Index(parent.Children, group[len(group)-1]) + 1
+
+	for len(nodes) < count {
+		n := &node{
+			IsLeaf: group[0].IsLeaf,
+			Parent: parent,
+		}
+		parent.Children = slices.Insert(parent.Children, insertAt, n)
+		insertAt++
+		nodes = append(nodes, n)
+	}
+
+	start := 0
+	for i, n := range nodes {
+		size := len(entries) / count
+		if i < len(entries)%count {
+			size++
+		}
+
+		n.Children = slices.Clone(entries[start : start+size])
+		start += size
+
+		t.adjustEntriesParent(n)
+		t.updateNodeMBR(n)
+	}
+
+	if count > len(group) {
+		t.observeStructure(StructureSplit, group[0].IsLeaf, len(entries))
+	}
+}
+
+// splitRootHilbert splits the root in two halves, following the Hilbert order, under a new root.
+func (t *RTree) splitRootHilbert(root *node) {
+
+	half := len(root.Children) / 2
+
+	sibling := &node{
+		IsLeaf:   root.IsLeaf,
+		Children: slices.Clone(root.Children[half:]),
+	}
+	root.Children = slices.Clone(root.Children[:half])
+
+	newRoot := &node{
+		Children: []*node{root, sibling},
+	}
+
+	root.Parent = newRoot
+	sibling.Parent = newRoot
+
+	t.adjustEntriesParent(root)
+	t.adjustEntriesParent(sibling)
+	t.updateNodeMBR(root)
+	t.updateNodeMBR(sibling)
+	t.updateNodeMBR(newRoot)
+
+	t.root = newRoot
+
+	t.observeStructure(StructureSplit, root.IsLeaf, len(root.Children)+len(sibling.Children))
+}
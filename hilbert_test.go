@@ -0,0 +1,95 @@
+package gortree_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+)
+
+func randomLocations(n int, seed uint64) []*Location {
+	rnd := rand.New(rand.NewPCG(seed, seed))
+	locations := make([]*Location, n)
+	for i := range locations {
+		locations[i] = &Location{
+			Name:        fmt.Sprintf("loc-%d", i),
+			Coordinates: [2]float64{rnd.Float64()*360 - 180, rnd.Float64()*180 - 90},
+		}
+	}
+	return locations
+}
+
+func bruteForceQuery(locations []*Location, r gortree.Rect) int {
+	count := 0
+	for _, l := range locations {
+		if bbox := l.BoundingBox(); bbox.Intersects(r) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestNewRTreeWithOptions(t *testing.T) {
+
+	rt, err := gortree.NewRTreeWithOptions(gortree.WithHilbert(*WholeWorld), gortree.WithMinMax(3, 9))
+	if err != nil {
+		t.Fatalf("NewRTreeWithOptions: %v", err)
+	}
+
+	if rt.Mode() != gortree.ModeHilbert {
+		t.Errorf("Expected mode %s, got %s", gortree.ModeHilbert, rt.Mode())
+	}
+
+	if rt.Min() != 3 || rt.Max() != 9 {
+		t.Errorf("Expected min 3 and max 9, got %d and %d", rt.Min(), rt.Max())
+	}
+
+	if _, err := gortree.NewRTreeWithOptions(gortree.WithHilbert(gortree.Rect{})); err == nil {
+		t.Errorf("Expected error for empty hilbert domain")
+	}
+}
+
+func TestHilbertRTree_InsertQueryDelete(t *testing.T) {
+
+	rt, err := gortree.NewRTreeWithOptions(gortree.WithHilbert(*WholeWorld))
+	if err != nil {
+		t.Fatalf("NewRTreeWithOptions: %v", err)
+	}
+
+	locations := randomLocations(2000, 1)
+	for _, l := range locations {
+		rt.Insert(l)
+	}
+
+	queries := []gortree.Rect{
+		*WholeWorld,
+		*NorthAmerica,
+		*gortree.NewRect(0, 0, 10, 10),
+		*gortree.NewRect(-50, -20, 30, 45),
+	}
+
+	for _, q := range queries {
+		if got, expected := len(rt.Query(q)), bruteForceQuery(locations, q); got != expected {
+			t.Errorf("Expected %d entries in %v, got %d", expected, q, got)
+		}
+	}
+
+	// Delete every other location
+	var remaining []*Location
+	for i, l := range locations {
+		if i%2 == 0 {
+			if err := rt.Delete(l); err != nil {
+				t.Fatalf("Delete %s: %v", l.ID(), err)
+			}
+		} else {
+			remaining = append(remaining, l)
+		}
+	}
+
+	for _, q := range queries {
+		if got, expected := len(rt.Query(q)), bruteForceQuery(remaining, q); got != expected {
+			t.Errorf("Expected %d entries in %v after delete, got %d", expected, q, got)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package gortree
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// Pair is an entry of a tree matched with an entry of another tree, along with the distance between their boxes.
+type Pair struct {
+	A, B     Spatial
+	Distance float64
+}
+
+// boxDistance returns the Euclidean distance between the closest points of two boxes, zero when they intersect. Only
+// the dimensions shared by both boxes are measured.
+func boxDistance(a, b Box) float64 {
+
+	sum := 0.0
+	for i := 0; i < min(a.Dims, b.Dims); i++ {
+		var d float64
+		switch {
+		case a.Max[i] < b.Min[i]:
+			d = b.Min[i] - a.Max[i]
+		case b.Max[i] < a.Min[i]:
+			d = a.Min[i] - b.Max[i]
+		}
+		sum += d * d
+	}
+
+	return math.Sqrt(sum)
+}
+
+// rlockBoth read-locks both trees, once when they are the same, and returns the function unlocking them. The trees
+// are locked in the order of their ids: a read lock waits for the pending writers, so joins of the same trees taken in
+// opposite orders could otherwise wait for each other.
+func rlockBoth(a, b *RTree) func() {
+
+	if a == b {
+		a.mu.RLock()
+		return a.mu.RUnlock
+	}
+
+	first, second := a, b
+	if b.id < a.id {
+		first, second = b, a
+	}
+
+	first.mu.RLock()
+	second.mu.RLock()
+
+	return func() {
+		second.mu.RUnlock()
+		first.mu.RUnlock()
+	}
+}
+
+// DistanceJoin finds all the pairs of an entry of a and an entry of b whose boxes are within d of each other. Both
+// trees are traversed together, descending only the pairs of nodes within d. The pairs are in no particular order.
+func DistanceJoin(a, b *RTree, d float64) []Pair {
+
+	defer rlockBoth(a, b)()
+
+	pairs := make([]Pair, 0)
+	nowA, nowB := a.now(), b.now()
+
+	type nodePair struct{ a, b *node }
+
+	// Children of n within d of other, n itself when it's an entry
+	expand := func(n, other *node, now int64) []*node {
+		if n.Data != nil {
+			return []*node{n}
+		}
+		var children []*node
+		for _, child := range n.Children {
+			if child.Data != nil && expired(child, now) {
+				continue
+			}
+			if boxDistance(child.BoundingBox, other.BoundingBox) <= d {
+				children = append(children, child)
+			}
+		}
+		return children
+	}
+
+	if len(a.root.Children) == 0 || len(b.root.Children) == 0 || boxDistance(a.root.BoundingBox, b.root.BoundingBox) > d {
+		return pairs
+	}
+
+	stack := NewStackFrom(nodePair{a.root, b.root})
+
+	for !stack.Empty() {
+
+		cur, _ := stack.Pop()
+
+		if cur.a.Data != nil && cur.b.Data != nil {
+			pairs = append(pairs, Pair{A: cur.a.Data, B: cur.b.Data, Distance: boxDistance(cur.a.BoundingBox, cur.b.BoundingBox)})
+			continue
+		}
+
+		for _, ca := range expand(cur.a, cur.b, nowA) {
+			for _, cb := range expand(cur.b, ca, nowB) {
+				if boxDistance(ca.BoundingBox, cb.BoundingBox) <= d {
+					stack.Push(nodePair{ca, cb})
+				}
+			}
+		}
+	}
+
+	return pairs
+}
+
+// KNNJoin finds, for each entry of a, the k entries of b with the closest boxes. The pairs are grouped by entry of a,
+// each group ordered by increasing distance, with fewer than k pairs when b is smaller. Each leaf of a is matched
+// against b with a best-first search, which stops at the nodes of b farther from the leaf than the k-th neighbour of
+// all its entries.
+func KNNJoin(a, b *RTree, k int) []Pair {
+
+	defer rlockBoth(a, b)()
+
+	pairs := make([]Pair, 0)
+	if k <= 0 || len(a.root.Children) == 0 || len(b.root.Children) == 0 {
+		return pairs
+	}
+
+	nowA, nowB := a.now(), b.now()
+
+	stack := NewStackFrom(a.root)
+
+	for !stack.Empty() {
+
+		leaf, _ := stack.Pop()
+
+		if !leaf.IsLeaf {
+			stack.Push(leaf.Children...)
+			continue
+		}
+
+		var entries []*node
+		for _, e := range leaf.Children {
+			if !expired(e, nowA) {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		for _, neighbours := range nearestToLeaf(b, leaf.BoundingBox, entries, k, nowB) {
+			pairs = append(pairs, neighbours...)
+		}
+	}
+
+	return pairs
+}
+
+// nearestToLeaf finds the k entries of t closest to each of the entries of a leaf bounded by bbox. Requires t.mu held.
+func nearestToLeaf(t *RTree, bbox Box, entries []*node, k int, now int64) [][]Pair {
+
+	neighbours := make([][]Pair, len(entries))
+
+	// bound is the largest k-th neighbour distance among the entries, infinite until all of them have k neighbours
+	bound := func() float64 {
+		worst := 0.0
+		for _, n := range neighbours {
+			if len(n) < k {
+				return math.Inf(1)
+			}
+			worst = max(worst, n[k-1].Distance)
+		}
+		return worst
+	}
+
+	queue := &distanceQueue{{node: t.root, dist: boxDistance(bbox, t.root.BoundingBox)}}
+	limit := math.Inf(1)
+
+	for queue.Len() > 0 {
+
+		cur := heap.Pop(queue).(distanceItem)
+
+		// Nothing left can improve the neighbours of any entry
+		if cur.dist > limit {
+			break
+		}
+
+		if cur.node.Data == nil {
+			for _, child := range cur.node.Children {
+				if child.Data != nil && expired(child, now) {
+					continue
+				}
+				heap.Push(queue, distanceItem{node: child, dist: boxDistance(bbox, child.BoundingBox)})
+			}
+			continue
+		}
+
+		for i, e := range entries {
+
+			d := boxDistance(e.BoundingBox, cur.node.BoundingBox)
+			n := neighbours[i]
+			if len(n) == k && d >= n[k-1].Distance {
+				continue
+			}
+
+			// Insert in distance order, dropping the farthest beyond k
+			at := sort.Search(len(n), func(j int) bool { return n[j].Distance > d })
+			if len(n) < k {
+				n = append(n, Pair{})
+			}
+			copy(n[at+1:], n[at:len(n)-1])
+			n[at] = Pair{A: e.Data, B: cur.node.Data, Distance: d}
+			neighbours[i] = n
+		}
+
+		limit = bound()
+	}
+
+	return neighbours
+}
@@ -0,0 +1,160 @@
+package gortree_test
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lambertmata/gortree"
+)
+
+func distance(a, b *Location) float64 {
+	return math.Hypot(a.Coordinates[0]-b.Coordinates[0], a.Coordinates[1]-b.Coordinates[1])
+}
+
+func pairKeys(pairs []gortree.Pair) []string {
+	keys := make([]string, len(pairs))
+	for i, p := range pairs {
+		keys[i] = p.A.ID() + "|" + p.B.ID()
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestDistanceJoin(t *testing.T) {
+
+	as, bs := randomLocations(800, 21), randomLocations(1200, 22)
+
+	a, _ := gortree.NewRTreeWithOptions(gortree.WithMinMax(4, 16))
+	a.InsertBatch(toSpatial(as))
+
+	b := gortree.NewRTree()
+	for _, l := range bs {
+		b.Insert(l)
+	}
+
+	for _, d := range []float64{0.5, 3, 10} {
+
+		pairs := gortree.DistanceJoin(a, b, d)
+
+		var want []string
+		for _, la := range as {
+			for _, lb := range bs {
+				if distance(la, lb) <= d {
+					want = append(want, la.Name+"|"+lb.Name)
+				}
+			}
+		}
+		sort.Strings(want)
+
+		if got := pairKeys(pairs); !slices.Equal(got, want) {
+			t.Fatalf("DistanceJoin(d=%v) = %d pairs, want %d", d, len(got), len(want))
+		}
+
+		for _, p := range pairs {
+			if want := distance(p.A.(*Location), p.B.(*Location)); math.Abs(p.Distance-want) > 1e-9 {
+				t.Fatalf("pair %s|%s distance %v, want %v", p.A.ID(), p.B.ID(), p.Distance, want)
+			}
+		}
+	}
+
+	// A tree joined with itself pairs every entry with itself
+	if pairs := gortree.DistanceJoin(a, a, 0); len(pairs) != len(as) {
+		t.Errorf("DistanceJoin(a, a, 0) = %d pairs, want %d", len(pairs), len(as))
+	}
+
+	if pairs := gortree.DistanceJoin(a, gortree.NewRTree(), 100); len(pairs) != 0 {
+		t.Errorf("DistanceJoin with an empty tree = %d pairs, want none", len(pairs))
+	}
+}
+
+func TestKNNJoin(t *testing.T) {
+
+	as, bs := randomLocations(500, 31), randomLocations(2000, 32)
+
+	a := gortree.NewRTree()
+	a.InsertBatch(toSpatial(as))
+
+	b, _ := gortree.NewRTreeWithOptions(gortree.WithMinMax(4, 16))
+	b.InsertBatch(toSpatial(bs))
+
+	const k = 5
+
+	pairs := gortree.KNNJoin(a, b, k)
+	if len(pairs) != len(as)*k {
+		t.Fatalf("KNNJoin = %d pairs, want %d", len(pairs), len(as)*k)
+	}
+
+	groups := make(map[string][]gortree.Pair)
+	for _, p := range pairs {
+		groups[p.A.ID()] = append(groups[p.A.ID()], p)
+	}
+
+	for _, la := range as {
+
+		byDistance := slices.Clone(bs)
+		slices.SortFunc(byDistance, func(x, y *Location) int {
+			return int(math.Copysign(1, distance(la, x)-distance(la, y)))
+		})
+
+		var want []string
+		for _, lb := range byDistance[:k] {
+			want = append(want, lb.Name)
+		}
+
+		var got []string
+		for _, p := range groups[la.Name] {
+			got = append(got, p.B.ID())
+		}
+
+		if !slices.Equal(got, want) {
+			t.Fatalf("KNNJoin neighbours of %s = %v, want %v", la.Name, got, want)
+		}
+	}
+
+	// Fewer than k entries in b
+	small := gortree.NewRTree()
+	for i := 0; i < 3; i++ {
+		small.Insert(&Location{Name: fmt.Sprintf("s%d", i), Coordinates: [2]float64{float64(i), 0}})
+	}
+	if pairs := gortree.KNNJoin(a, small, k); len(pairs) != len(as)*3 {
+		t.Errorf("KNNJoin with 3 entries in b = %d pairs, want %d", len(pairs), len(as)*3)
+	}
+
+	if pairs := gortree.KNNJoin(a, b, 0); len(pairs) != 0 {
+		t.Errorf("KNNJoin(k=0) = %d pairs, want none", len(pairs))
+	}
+}
+
+func TestJoin_Concurrent(t *testing.T) {
+
+	a, b := gortree.NewRTree(), gortree.NewRTree()
+	a.InsertBatch(toSpatial(randomLocations(200, 41)))
+	b.InsertBatch(toSpatial(randomLocations(200, 42)))
+
+	// Joins in both orders, with writers waiting on both trees
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(4)
+		go func() { defer wg.Done(); gortree.DistanceJoin(a, b, 5) }()
+		go func() { defer wg.Done(); gortree.KNNJoin(b, a, 2) }()
+		go func() { defer wg.Done(); a.Insert(&Location{Name: fmt.Sprintf("a-extra-%d", i)}) }()
+		go func() { defer wg.Done(); b.Insert(&Location{Name: fmt.Sprintf("b-extra-%d", i)}) }()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected concurrent joins and writes to complete")
+	}
+}
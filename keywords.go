@@ -0,0 +1,117 @@
+package gortree
+
+import "hash/fnv"
+
+// Keyworded is implemented by entries carrying a set of keywords, such as the categories of a place, searched by
+// QueryKeywords and NearestKeywords. Keywords are compared as they are, case included.
+type Keyworded interface {
+	Spatial
+	Keywords() []string
+}
+
+// signatureBits is the size of a keyword signature.
+const signatureBits = 256
+
+// signature is a bitmap of hashed keywords, one bit per keyword. Nodes keep the union of the signatures of their
+// entries: a subtree whose signature misses the bits of all the searched terms contains none of them, while a
+// signature with those bits may be a false positive, when different keywords share a bit.
+type signature [signatureBits / 64]uint64
+
+// keywordBit returns the bit of the keyword in a signature.
+func keywordBit(keyword string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(keyword))
+	return h.Sum32() % signatureBits
+}
+
+// signatureOf returns the signature of the keywords.
+func signatureOf(keywords []string) signature {
+	var s signature
+	for _, k := range keywords {
+		bit := keywordBit(k)
+		s[bit/64] |= 1 << (bit % 64)
+	}
+	return s
+}
+
+// keywordsOf returns the keywords of data, none when it doesn't implement Keyworded.
+func keywordsOf(data Spatial) []string {
+	if keyworded, ok := data.(Keyworded); ok {
+		return keyworded.Keywords()
+	}
+	return nil
+}
+
+// union returns the signature of the keywords of both signatures.
+func (s signature) union(other signature) signature {
+	for i := range s {
+		s[i] |= other[i]
+	}
+	return s
+}
+
+// intersects tells whether the signatures share a bit.
+func (s signature) intersects(other signature) bool {
+	for i := range s {
+		if s[i]&other[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// computeNodesSignature returns the union of the keyword signatures of all nodes.
+func computeNodesSignature(nodes []*node) signature {
+	var s signature
+	for _, n := range nodes {
+		s = s.union(n.Keywords)
+	}
+	return s
+}
+
+// keywordFilter returns the search filter keeping the subtrees whose signature shares a bit with the terms, and the
+// entries carrying at least one of the terms.
+func keywordFilter(terms []string) func(n *node) bool {
+
+	query := signatureOf(terms)
+
+	return func(n *node) bool {
+
+		if !n.Keywords.intersects(query) {
+			return false
+		}
+		if n.Data == nil {
+			return true
+		}
+
+		// The signature may be a false positive
+		for _, k := range keywordsOf(n.Data) {
+			for _, term := range terms {
+				if k == term {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// QueryKeywords finds all items intersecting the given Rect and carrying at least one of the terms among their
+// keywords. Subtrees are pruned on both their bounding box and the signature of their entries keywords.
+func (t *RTree) QueryKeywords(r Rect, terms []string) []Spatial {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.search(OpQueryKeywords, r.Box(), keywordFilter(terms))
+}
+
+// NearestKeywords finds the k entries closest to p carrying at least one of the terms among their keywords, ordered
+// by increasing distance. Subtrees whose keywords signature has none of the terms are skipped.
+func (t *RTree) NearestKeywords(p Point, k int, terms []string) []Spatial {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.nearest(OpNearestKeywords, p, k, keywordFilter(terms))
+}
@@ -0,0 +1,146 @@
+package gortree_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+)
+
+// Place is a location tagged with keywords.
+type Place struct {
+	*Location
+	Tags []string
+}
+
+func (p *Place) Keywords() []string {
+	return p.Tags
+}
+
+func hasAny(p *Place, terms []string) bool {
+	for _, term := range terms {
+		if slices.Contains(p.Tags, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// randomPlaces tags random locations with a few of many keywords, some rare.
+func randomPlaces(n int, seed uint64) []*Place {
+
+	rnd := rand.New(rand.NewPCG(seed, seed))
+	places := make([]*Place, n)
+
+	for i, l := range randomLocations(n, seed) {
+		p := &Place{Location: l}
+		for j := rnd.IntN(4); j > 0; j-- {
+			p.Tags = append(p.Tags, fmt.Sprintf("tag-%d", rnd.IntN(200)))
+		}
+		if i%250 == 0 {
+			p.Tags = append(p.Tags, "coffee")
+		}
+		places[i] = p
+	}
+
+	return places
+}
+
+func TestRTree_QueryKeywords(t *testing.T) {
+
+	rec := &recorder{}
+	rt, _ := gortree.NewRTreeWithOptions(gortree.WithMinMax(4, 16), gortree.WithObserver(rec))
+
+	places := randomPlaces(3000, 17)
+	for _, p := range places {
+		rt.Insert(p)
+	}
+	for _, p := range places[:300] {
+		_ = rt.Delete(p)
+	}
+	places = places[300:]
+
+	if err := rt.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	rnd := rand.New(rand.NewPCG(18, 19))
+
+	for i := 0; i < 30; i++ {
+
+		x, y := rnd.Float64()*360-180, rnd.Float64()*180-90
+		window := *gortree.NewRect(x, y, x+rnd.Float64()*180, y+rnd.Float64()*90)
+		terms := []string{fmt.Sprintf("tag-%d", rnd.IntN(200)), fmt.Sprintf("tag-%d", rnd.IntN(200))}
+
+		var want []string
+		for _, p := range places {
+			if bbox := p.BoundingBox(); bbox.Intersects(window) && hasAny(p, terms) {
+				want = append(want, p.ID())
+			}
+		}
+		slices.Sort(want)
+
+		if got := ids(rt.QueryKeywords(window, terms)); !slices.Equal(got, want) {
+			t.Fatalf("QueryKeywords(%v, %v) = %v, want %v", window, terms, got, want)
+		}
+	}
+
+	// A rare keyword prunes most of the tree
+	rec.queries = nil
+	coffee := rt.QueryKeywords(*WholeWorld, []string{"coffee"})
+
+	if len(coffee) != 10 {
+		t.Errorf("QueryKeywords(coffee) = %d entries, want 10", len(coffee))
+	}
+	if stats := rec.queries[0]; stats.Op != gortree.OpQueryKeywords || stats.EntriesTested >= len(places)/4 {
+		t.Errorf("QueryKeywords(coffee) stats %+v, want few entries tested", stats)
+	}
+
+	if got := rt.QueryKeywords(*WholeWorld, nil); len(got) != 0 {
+		t.Errorf("QueryKeywords without terms = %d entries, want none", len(got))
+	}
+}
+
+func TestRTree_NearestKeywords(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	places := randomPlaces(2000, 23)
+	items := make([]gortree.Spatial, len(places))
+	for i, pl := range places {
+		items[i] = pl
+	}
+	rt.InsertBatch(items)
+
+	// Untagged entries are never found
+	rt.Insert(&Location{Name: "untagged", Coordinates: [2]float64{0, 0}})
+
+	p := gortree.Point{0, 0}
+	terms := []string{"coffee", "tag-7"}
+
+	var matching []*Place
+	for _, pl := range places {
+		if hasAny(pl, terms) {
+			matching = append(matching, pl)
+		}
+	}
+	distance := func(pl *Place) float64 { return math.Hypot(pl.Coordinates[0], pl.Coordinates[1]) }
+	slices.SortFunc(matching, func(a, b *Place) int { return int(math.Copysign(1, distance(a)-distance(b))) })
+
+	got := rt.NearestKeywords(p, 5, terms)
+	if len(got) != 5 {
+		t.Fatalf("Expected 5 results, got %d", len(got))
+	}
+
+	for i, item := range got {
+		if item.ID() != matching[i].ID() {
+			t.Errorf("Expected %s at %d, got %s", matching[i].ID(), i, item.ID())
+		}
+	}
+
+	if got := rt.NearestKeywords(p, 5000, terms); len(got) != len(matching) {
+		t.Errorf("Expected all %d matching entries, got %d", len(matching), len(got))
+	}
+}
@@ -0,0 +1,141 @@
+// Package metrics exposes the queries and structural changes of a gortree.RTree as Prometheus metrics.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/lambertmata/gortree"
+)
+
+// queryCounters holds the totals of a kind of query.
+type queryCounters struct {
+	count         uint64
+	nodesVisited  uint64
+	leavesVisited uint64
+	entriesTested uint64
+	results       uint64
+	seconds       float64
+}
+
+// Prometheus is a gortree.Observer counting the queries and structural changes of the observed trees. It serves the
+// counters in the Prometheus text exposition format.
+type Prometheus struct {
+	namespace string
+
+	mu        sync.Mutex
+	queries   map[gortree.QueryOp]*queryCounters
+	structure map[gortree.StructureKind]uint64
+	entries   map[gortree.StructureKind]uint64
+}
+
+// NewPrometheus creates an observer whose metric names start with namespace, "gortree" when empty.
+func NewPrometheus(namespace string) *Prometheus {
+
+	if namespace == "" {
+		namespace = "gortree"
+	}
+
+	return &Prometheus{
+		namespace: namespace,
+		queries:   make(map[gortree.QueryOp]*queryCounters),
+		structure: make(map[gortree.StructureKind]uint64),
+		entries:   make(map[gortree.StructureKind]uint64),
+	}
+}
+
+// ObserveQuery adds the query to the counters of its kind.
+func (p *Prometheus) ObserveQuery(s gortree.QueryStats) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.queries[s.Op]
+	if !ok {
+		c = &queryCounters{}
+		p.queries[s.Op] = c
+	}
+
+	c.count++
+	c.nodesVisited += uint64(s.NodesVisited)
+	c.leavesVisited += uint64(s.LeavesVisited)
+	c.entriesTested += uint64(s.EntriesTested)
+	c.results += uint64(s.Results)
+	c.seconds += s.Duration.Seconds()
+}
+
+// ObserveStructure adds the structural change to the counters of its kind.
+func (p *Prometheus) ObserveStructure(e gortree.StructureEvent) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.structure[e.Kind]++
+	p.entries[e.Kind] += uint64(e.Entries)
+}
+
+// ServeHTTP writes the counters in the Prometheus text exposition format, to be scraped from a local endpoint such as
+// /metrics.
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(p.String()))
+}
+
+// String returns the counters in the Prometheus text exposition format.
+func (p *Prometheus) String() string {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b strings.Builder
+
+	// Queries, sorted by kind so that the output is stable
+	ops := make([]gortree.QueryOp, 0, len(p.queries))
+	for op := range p.queries {
+		ops = append(ops, op)
+	}
+	slices.Sort(ops)
+
+	queryMetrics := []struct {
+		name, help string
+		value      func(c *queryCounters) string
+	}{
+		{"queries_total", "Queries run.", func(c *queryCounters) string { return fmt.Sprint(c.count) }},
+		{"query_nodes_visited_total", "Nodes reached by queries.", func(c *queryCounters) string { return fmt.Sprint(c.nodesVisited) }},
+		{"query_leaves_visited_total", "Leaves whose entries were tested by queries.", func(c *queryCounters) string { return fmt.Sprint(c.leavesVisited) }},
+		{"query_entries_tested_total", "Entries tested by queries.", func(c *queryCounters) string { return fmt.Sprint(c.entriesTested) }},
+		{"query_results_total", "Entries returned by queries.", func(c *queryCounters) string { return fmt.Sprint(c.results) }},
+		{"query_duration_seconds_total", "Time spent traversing the tree by queries.", func(c *queryCounters) string { return fmt.Sprint(c.seconds) }},
+	}
+
+	for _, m := range queryMetrics {
+		p.header(&b, m.name, m.help)
+		for _, op := range ops {
+			fmt.Fprintf(&b, "%s_%s{op=%q} %s\n", p.namespace, m.name, op.String(), m.value(p.queries[op]))
+		}
+	}
+
+	// Structural changes, always listed
+	kinds := []gortree.StructureKind{gortree.StructureSplit, gortree.StructureUnderflow, gortree.StructureReinsert}
+
+	p.header(&b, "structure_changes_total", "Structural changes of the tree: node splits, underflows and reinsertions.")
+	for _, kind := range kinds {
+		fmt.Fprintf(&b, "%s_structure_changes_total{kind=%q} %d\n", p.namespace, kind.String(), p.structure[kind])
+	}
+
+	p.header(&b, "structure_entries_total", "Entries moved by structural changes of the tree.")
+	for _, kind := range kinds {
+		fmt.Fprintf(&b, "%s_structure_entries_total{kind=%q} %d\n", p.namespace, kind.String(), p.entries[kind])
+	}
+
+	return b.String()
+}
+
+// header writes the HELP and TYPE lines of a counter.
+func (p *Prometheus) header(b *strings.Builder, name, help string) {
+	fmt.Fprintf(b, "# HELP %s_%s %s\n", p.namespace, name, help)
+	fmt.Fprintf(b, "# TYPE %s_%s counter\n", p.namespace, name)
+}
@@ -0,0 +1,87 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/metrics"
+)
+
+type Marker struct {
+	Name string
+	X, Y float64
+}
+
+func (m *Marker) ID() string {
+	return m.Name
+}
+
+func (m *Marker) BoundingBox() gortree.Rect {
+	return *gortree.NewRect(m.X, m.Y, m.X, m.Y)
+}
+
+func TestPrometheus(t *testing.T) {
+
+	prom := metrics.NewPrometheus("")
+	rt, err := gortree.NewRTreeWithOptions(gortree.WithObserver(prom))
+	if err != nil {
+		t.Fatalf("NewRTreeWithOptions: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		rt.Insert(&Marker{string(rune('a' + i)), float64(i), float64(i)})
+	}
+
+	rt.Query(*gortree.NewRect(0, 0, 2, 2))
+	rt.Query(*gortree.NewRect(5, 5, 5, 5))
+	rt.Nearest(gortree.Point{0, 0}, 1)
+
+	ts := httptest.NewServer(prom)
+	defer ts.Close()
+
+	res, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer res.Body.Close()
+
+	if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	body := string(b)
+
+	for _, line := range []string{
+		"# TYPE gortree_queries_total counter",
+		`gortree_queries_total{op="query"} 2`,
+		`gortree_queries_total{op="nearest"} 1`,
+		`gortree_query_results_total{op="query"} 4`,
+		`gortree_query_results_total{op="nearest"} 1`,
+		`gortree_structure_changes_total{kind="underflow"} 0`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected line %q in\n%s", line, body)
+		}
+	}
+
+	if strings.Contains(body, `gortree_structure_changes_total{kind="split"} 0`) {
+		t.Errorf("Expected splits counted in\n%s", body)
+	}
+
+	// Every sample line is a name, optional labels and a value
+	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) != 2 || !strings.HasPrefix(fields[0], "gortree_") {
+			t.Errorf("Invalid sample line %q", line)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package gortree
+
+import (
+	"container/heap"
+	"iter"
+	"math"
+	"time"
+)
+
+// Point is a position with one coordinate per dimension, such as (x, y) or (x, y, z). Dimensions of the tree missing
+// from a point are unconstrained.
+type Point []float64
+
+// Distance returns the Euclidean distance between the point and the closest point of the box, zero when the box
+// contains it. Only the dimensions shared by the point and the box are measured.
+func (p Point) Distance(b Box) float64 {
+
+	sum := 0.0
+	for i := 0; i < min(len(p), b.Dims); i++ {
+		var d float64
+		switch {
+		case p[i] < b.Min[i]:
+			d = b.Min[i] - p[i]
+		case p[i] > b.Max[i]:
+			d = p[i] - b.Max[i]
+		}
+		sum += d * d
+	}
+
+	return math.Sqrt(sum)
+}
+
+// Nearest finds the k entries closest to p, ordered by increasing distance. Distances are measured to the entries
+// box, so the entries containing p come first. Fewer than k entries are returned when the tree is smaller.
+func (t *RTree) Nearest(p Point, k int) []Spatial {
+	return t.NearestFunc(p, k, nil)
+}
+
+// NearestFunc finds the k entries closest to p for which keep returns true, ordered by increasing distance. The
+// entries are filtered during the search, which goes on until k of them are kept or the tree is exhausted. A nil keep
+// keeps all entries, like Nearest.
+func (t *RTree) NearestFunc(p Point, k int, keep func(Spatial) bool) []Spatial {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if keep == nil {
+		return t.nearest(OpNearest, p, k, nil)
+	}
+
+	return t.nearest(OpNearest, p, k, func(n *node) bool {
+		return n.Data == nil || keep(n.Data)
+	})
+}
+
+// nearest finds the k entries closest to p, hiding the expired ones. When filter is not nil, it is called with both
+// internal nodes and entry nodes: branches and entries it rejects are skipped. The query is reported to the observer
+// as op. Requires t.mu held.
+func (t *RTree) nearest(op QueryOp, p Point, k int, filter func(n *node) bool) []Spatial {
+
+	results := make([]Spatial, 0, max(k, 0))
+	if k <= 0 {
+		return results
+	}
+
+	start := t.startQuery()
+	stats := QueryStats{Op: op}
+	now := t.now()
+
+	// Best-first search: nodes and entries are popped by increasing distance, so that an entry popped is closer than
+	// everything left in the queue
+	queue := &distanceQueue{{node: t.root, dist: p.Distance(t.root.BoundingBox)}}
+
+	for queue.Len() > 0 && len(results) < k {
+
+		cur := heap.Pop(queue).(distanceItem)
+
+		if cur.node.Data != nil {
+			results = append(results, cur.node.Data)
+			continue
+		}
+
+		stats.NodesVisited++
+		if cur.node.IsLeaf {
+			stats.LeavesVisited++
+			stats.EntriesTested += len(cur.node.Children)
+		}
+
+		for _, child := range cur.node.Children {
+			if (child.Data != nil && expired(child, now)) || (filter != nil && !filter(child)) {
+				continue
+			}
+			heap.Push(queue, distanceItem{node: child, dist: p.Distance(child.BoundingBox)})
+		}
+	}
+
+	stats.Results = len(results)
+	t.observeQuery(stats, start)
+
+	return results
+}
+
+// NearestIter returns an iterator over the entries by increasing distance from p, paired with their distance, for when
+// the number of entries needed isn't known in advance. Like Nearest, it is a best-first search, resumed at each step
+// and stopped as soon as the loop breaks. The tree is read-locked while looking for each entry but not while the loop
+// body runs, which may write to the tree. After a write, the search starts over from the root, skipping the entries
+// closer than the last one and those already yielded at its distance: entries inserted or moved behind the search are
+// not seen.
+func (t *RTree) NearestIter(p Point) iter.Seq2[Spatial, float64] {
+	return func(yield func(Spatial, float64) bool) {
+
+		stats := QueryStats{Op: OpNearestIter}
+		var elapsed time.Duration
+
+		// Reported once the iteration is over, with the time spent looking for entries
+		defer func() {
+			t.mu.RLock()
+			defer t.mu.RUnlock()
+			if t.observer != nil {
+				stats.Duration = elapsed
+				t.observer.ObserveQuery(stats)
+			}
+		}()
+
+		var (
+			queue     *distanceQueue
+			version   uint64
+			restarted bool
+			last      float64
+			// The entries yielded at the last distance, the only ones a restart can find again
+			atLast = make(map[*node]struct{})
+		)
+
+		// next pops nodes until an entry comes out of the queue
+		next := func() (distanceItem, bool) {
+
+			t.mu.RLock()
+			defer t.mu.RUnlock()
+
+			start := t.startQuery()
+			defer func() {
+				if !start.IsZero() {
+					elapsed += time.Since(start)
+				}
+			}()
+
+			// The queued nodes may have been split or removed since the last step
+			if queue == nil || version != t.version {
+				restarted = queue != nil
+				queue = &distanceQueue{{node: t.root, dist: p.Distance(t.root.BoundingBox)}}
+				version = t.version
+			}
+
+			now := t.now()
+
+			for queue.Len() > 0 {
+
+				cur := heap.Pop(queue).(distanceItem)
+
+				if cur.node.Data != nil {
+					if cur.dist < last || expired(cur.node, now) {
+						continue
+					}
+					if _, ok := atLast[cur.node]; ok && restarted {
+						continue
+					}
+					return cur, true
+				}
+
+				stats.NodesVisited++
+				if cur.node.IsLeaf {
+					stats.LeavesVisited++
+					stats.EntriesTested += len(cur.node.Children)
+				}
+
+				for _, child := range cur.node.Children {
+					if child.Data != nil && expired(child, now) {
+						continue
+					}
+					heap.Push(queue, distanceItem{node: child, dist: p.Distance(child.BoundingBox)})
+				}
+			}
+
+			return distanceItem{}, false
+		}
+
+		for {
+			item, ok := next()
+			if !ok {
+				return
+			}
+			if item.dist > last {
+				clear(atLast)
+			}
+			atLast[item.node] = struct{}{}
+			last = item.dist
+			stats.Results++
+			if !yield(item.node.Data, item.dist) {
+				return
+			}
+		}
+	}
+}
+
+// QueryRadius finds all the entries whose box is within radius of p.
+func (t *RTree) QueryRadius(p Point, radius float64) []Spatial {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	// The box around the circle finds the candidates, the filter prunes its corners
+	b := Box{Dims: min(len(p), MaxDims)}
+	for i := 0; i < b.Dims; i++ {
+		b.Min[i] = p[i] - radius
+		b.Max[i] = p[i] + radius
+	}
+
+	return t.search(OpQueryRadius, b, func(n *node) bool {
+		return p.Distance(n.BoundingBox) <= radius
+	})
+}
+
+// distanceItem is a node queued by its distance from the query point.
+type distanceItem struct {
+	node *node
+	dist float64
+}
+
+// distanceQueue is a min-heap of nodes by distance, used through container/heap.
+type distanceQueue []distanceItem
+
+func (q distanceQueue) Len() int           { return len(q) }
+func (q distanceQueue) Less(i, j int) bool { return q[i].dist < q[j].dist }
+func (q distanceQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *distanceQueue) Push(x any)        { *q = append(*q, x.(distanceItem)) }
+
+func (q *distanceQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,270 @@
+package gortree_test
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+)
+
+func TestRTree_Nearest(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	locations := randomLocations(1000, 5)
+	for _, l := range locations {
+		rt.Insert(l)
+	}
+
+	p := gortree.Point{10, 45}
+	distance := func(l *Location) float64 {
+		return math.Hypot(l.Coordinates[0]-p[0], l.Coordinates[1]-p[1])
+	}
+
+	// Brute force
+	sorted := slices.Clone(locations)
+	slices.SortFunc(sorted, func(a, b *Location) int {
+		return int(math.Copysign(1, distance(a)-distance(b)))
+	})
+
+	got := rt.Nearest(p, 10)
+	if len(got) != 10 {
+		t.Fatalf("Expected 10 results, got %d", len(got))
+	}
+
+	for i, item := range got {
+		if item.ID() != sorted[i].ID() {
+			t.Errorf("Expected %s at %d, got %s", sorted[i].ID(), i, item.ID())
+		}
+	}
+
+	if got := rt.Nearest(p, 2000); len(got) != 1000 {
+		t.Errorf("Expected all 1000 entries, got %d", len(got))
+	}
+
+	if got := rt.Nearest(p, 0); len(got) != 0 {
+		t.Errorf("Expected no entries for k=0, got %d", len(got))
+	}
+}
+
+func TestRTree_NearestFunc(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	locations := randomLocations(1000, 8)
+	rt.InsertBatch(toSpatial(locations))
+
+	p := gortree.Point{0, 0}
+	distance := func(l *Location) float64 {
+		return math.Hypot(l.Coordinates[0]-p[0], l.Coordinates[1]-p[1])
+	}
+
+	// A rare attribute, far from p: post-filtering Nearest(p, 5) would keep none
+	polar := func(item gortree.Spatial) bool {
+		return math.Abs(item.(*Location).Coordinates[1]) > 80
+	}
+
+	var matching []*Location
+	for _, l := range locations {
+		if polar(l) {
+			matching = append(matching, l)
+		}
+	}
+	slices.SortFunc(matching, func(a, b *Location) int {
+		return int(math.Copysign(1, distance(a)-distance(b)))
+	})
+
+	got := rt.NearestFunc(p, 5, polar)
+	if len(got) != 5 {
+		t.Fatalf("Expected 5 results, got %d", len(got))
+	}
+
+	for i, item := range got {
+		if item.ID() != matching[i].ID() {
+			t.Errorf("Expected %s at %d, got %s", matching[i].ID(), i, item.ID())
+		}
+	}
+
+	if got := rt.NearestFunc(p, 5000, polar); len(got) != len(matching) {
+		t.Errorf("Expected all %d matching entries, got %d", len(matching), len(got))
+	}
+}
+
+func TestRTree_NearestIter(t *testing.T) {
+
+	rec := &recorder{}
+	rt, _ := gortree.NewRTreeWithOptions(gortree.WithObserver(rec))
+	locations := randomLocations(1000, 7)
+	rt.InsertBatch(toSpatial(locations))
+
+	p := gortree.Point{-60, 20}
+
+	// Every entry, in the order of Nearest
+	want := rt.Nearest(p, len(locations))
+	previous := 0.0
+	i := 0
+
+	for item, dist := range rt.NearestIter(p) {
+		if item.ID() != want[i].ID() {
+			t.Fatalf("Expected %s at %d, got %s", want[i].ID(), i, item.ID())
+		}
+		if dist < previous {
+			t.Fatalf("Expected increasing distances, got %v after %v", dist, previous)
+		}
+		if l := item.(*Location); math.Abs(dist-math.Hypot(l.Coordinates[0]-p[0], l.Coordinates[1]-p[1])) > 1e-9 {
+			t.Fatalf("Wrong distance %v for %s", dist, item.ID())
+		}
+		previous = dist
+		i++
+	}
+
+	if i != len(locations) {
+		t.Fatalf("Expected %d entries, got %d", len(locations), i)
+	}
+
+	// Taking entries until one satisfies a filter, writing to the tree from the loop body
+	rec.queries = nil
+	var found gortree.Spatial
+	for item := range rt.NearestIter(p) {
+		if item.(*Location).Coordinates[1] < 0 {
+			found = item
+			break
+		}
+		rt.Insert(&Location{Name: "extra-" + item.ID(), Coordinates: [2]float64{100, 80}})
+	}
+
+	if found == nil {
+		t.Fatal("Expected an entry in the southern hemisphere")
+	}
+
+	if len(rec.queries) != 1 || rec.queries[0].Op != gortree.OpNearestIter {
+		t.Fatalf("Expected a single nearest_iter query reported, got %+v", rec.queries)
+	}
+	if stats := rec.queries[0]; stats.EntriesTested >= len(locations) {
+		t.Errorf("Expected the search to stop early, %d entries tested", stats.EntriesTested)
+	}
+
+	for range gortree.NewRTree().NearestIter(p) {
+		t.Fatal("Expected no entries from an empty tree")
+	}
+}
+
+func TestRTree_NearestIter_Writes(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	for i := 0; i < 40; i++ {
+		rt.Insert(&Location{Name: fmt.Sprintf("a%d", i), Coordinates: [2]float64{float64(i), float64(i % 7)}})
+	}
+
+	p := gortree.Point{0, 0}
+	seen := make(map[string]int)
+	previous := 0.0
+	step := 0
+
+	// The loop body inserts entries, splitting nodes still queued by the search
+	for item, dist := range rt.NearestIter(p) {
+
+		seen[item.ID()]++
+		if dist < previous {
+			t.Fatalf("Expected increasing distances, got %v after %v", dist, previous)
+		}
+		previous = dist
+
+		if step < 9 {
+			for j := 0; j < 5; j++ {
+				rt.Insert(&Location{Name: fmt.Sprintf("b%d-%d", step, j), Coordinates: [2]float64{float64(step*5 + j), 3.5}})
+			}
+		}
+		step++
+	}
+
+	for i := 0; i < 40; i++ {
+		if id := fmt.Sprintf("a%d", i); seen[id] != 1 {
+			t.Errorf("Expected %s once, got it %d times", id, seen[id])
+		}
+	}
+}
+
+func TestRTree_NearestIter_SharedIDs(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	for i := 0; i < 30; i++ {
+		// Distinct entries sharing an ID, three of them at each distance
+		rt.Insert(&Location{Name: "shared", Coordinates: [2]float64{float64(i / 3), 0}})
+	}
+
+	p := gortree.Point{0, 0}
+
+	count := 0
+	for range rt.NearestIter(p) {
+		count++
+	}
+	if want := len(rt.Nearest(p, 100)); count != want {
+		t.Errorf("Expected %d entries, got %d", want, count)
+	}
+
+	// Entries tied at the last distance are yielded once after a restart
+	count = 0
+	for item, dist := range rt.NearestIter(p) {
+		if item.ID() == "shared" {
+			count++
+		}
+		if dist == 0 && count < 3 {
+			rt.Insert(&Location{Name: fmt.Sprintf("far-%d", count), Coordinates: [2]float64{100, 100}})
+		}
+	}
+	if count != 30 {
+		t.Errorf("Expected 30 shared entries with writes, got %d", count)
+	}
+}
+
+func TestRTree_QueryRadius(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	locations := randomLocations(1000, 6)
+	for _, l := range locations {
+		rt.Insert(l)
+	}
+
+	p := gortree.Point{-20, 10}
+	radius := 30.0
+
+	expected := 0
+	for _, l := range locations {
+		if math.Hypot(l.Coordinates[0]-p[0], l.Coordinates[1]-p[1]) <= radius {
+			expected++
+		}
+	}
+
+	got := rt.QueryRadius(p, radius)
+	if len(got) != expected {
+		t.Errorf("Expected %d entries within %g, got %d", expected, radius, len(got))
+	}
+
+	// The query box corners are excluded
+	if len(got) >= len(rt.Query(*gortree.NewRect(p[0]-radius, p[1]-radius, p[0]+radius, p[1]+radius))) {
+		t.Errorf("Expected fewer entries in the circle than in its bounding box")
+	}
+}
+
+func TestPoint_Distance(t *testing.T) {
+
+	b := gortree.NewRect(0, 0, 10, 10).Box()
+
+	testCases := []struct {
+		Name     string
+		Point    gortree.Point
+		Expected float64
+	}{
+		{"Inside", gortree.Point{5, 5}, 0},
+		{"Side", gortree.Point{15, 5}, 5},
+		{"Corner", gortree.Point{13, 14}, 5},
+		{"Extra dimension ignored", gortree.Point{5, 5, 100}, 0},
+	}
+
+	for _, testCase := range testCases {
+		if got := testCase.Point.Distance(b); got != testCase.Expected {
+			t.Errorf("Expected %g for %s, got %g", testCase.Expected, testCase.Name, got)
+		}
+	}
+}
@@ -1,19 +1,30 @@
 package gortree
 
 type node struct {
-	BoundingBox Rect
+	BoundingBox Box
 	IsLeaf      bool
 	Children    []*node
 	Parent      *node
 	Data        Spatial
+	LHV         uint64    // Largest Hilbert value in the subtree, or the entry's own value
+	Validity    interval  // Union of the entries validity in the subtree, or the entry's own validity
+	Expiry      int64     // Earliest expiry in the subtree, or the entry's own expiry, in Unix nanoseconds
+	Summary     Summary   // Count and aggregated values of the entries in the subtree, or of the entry itself
+	MaxScore    float64   // Highest score in the subtree, or the entry's own score
+	Keywords    signature // Signature of the keywords in the subtree, or of the entry's own keywords
 }
 
-// newLeafNode creates an entry node with data.
-func newLeafNode(data Spatial) *node {
+// newLeafNode creates an entry node with data, indexed in the given number of dimensions.
+func newLeafNode(data Spatial, dims int) *node {
 
 	newEntry := &node{
 		Data:        data,
-		BoundingBox: data.BoundingBox(),
+		BoundingBox: boxOf(data, dims),
+		Validity:    validityOf(data),
+		Expiry:      noExpiry,
+		Summary:     Summary{Count: 1},
+		MaxScore:    scoreOf(data),
+		Keywords:    signatureOf(keywordsOf(data)),
 	}
 
 	return newEntry
@@ -0,0 +1,151 @@
+package gortree
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// QueryOp identifies the kind of query reported to an Observer.
+type QueryOp int
+
+const (
+	// OpQuery is Query, QueryBox and QueryFunc.
+	OpQuery QueryOp = iota
+	// OpQueryDuring is QueryDuring, QueryBoxDuring and QueryAt.
+	OpQueryDuring
+	// OpQueryRadius is QueryRadius.
+	OpQueryRadius
+	// OpNearest is Nearest and NearestFunc.
+	OpNearest
+	// OpCount is Count.
+	OpCount
+	// OpAggregate is Aggregate.
+	OpAggregate
+	// OpGridCount is GridCount.
+	OpGridCount
+	// OpNearestIter is a NearestIter iteration, reported once it is over.
+	OpNearestIter
+	// OpTopK is TopK.
+	OpTopK
+	// OpQueryKeywords is QueryKeywords.
+	OpQueryKeywords
+	// OpNearestKeywords is NearestKeywords.
+	OpNearestKeywords
+)
+
+// String returns the query name.
+func (op QueryOp) String() string {
+	switch op {
+	case OpQuery:
+		return "query"
+	case OpQueryDuring:
+		return "query_during"
+	case OpQueryRadius:
+		return "query_radius"
+	case OpNearest:
+		return "nearest"
+	case OpCount:
+		return "count"
+	case OpAggregate:
+		return "aggregate"
+	case OpGridCount:
+		return "grid_count"
+	case OpNearestIter:
+		return "nearest_iter"
+	case OpTopK:
+		return "top_k"
+	case OpQueryKeywords:
+		return "query_keywords"
+	case OpNearestKeywords:
+		return "nearest_keywords"
+	default:
+		return fmt.Sprintf("QueryOp(%d)", int(op))
+	}
+}
+
+// QueryStats describes the work done by a query.
+type QueryStats struct {
+	Op            QueryOp
+	NodesVisited  int           // Internal and leaf nodes reached, including those then pruned
+	LeavesVisited int           // Leaves whose entries were tested
+	EntriesTested int           // Entries tested against the query
+	Results       int           // Entries returned
+	Duration      time.Duration // Time spent traversing the tree, excluding the wait for the lock
+}
+
+// StructureKind identifies a structural change of the tree.
+type StructureKind int
+
+const (
+	// StructureSplit is a node split, either quadratic or, in ModeHilbert, 2-to-3 or of the root.
+	StructureSplit StructureKind = iota
+	// StructureUnderflow is a node removed by condenseTree after a delete left it with fewer than min entries.
+	StructureUnderflow
+	// StructureReinsert is the reinsertion of the entries orphaned by underflows.
+	StructureReinsert
+)
+
+// String returns the structural change name.
+func (k StructureKind) String() string {
+	switch k {
+	case StructureSplit:
+		return "split"
+	case StructureUnderflow:
+		return "underflow"
+	case StructureReinsert:
+		return "reinsert"
+	default:
+		return fmt.Sprintf("StructureKind(%d)", int(k))
+	}
+}
+
+// StructureEvent describes a structural change of the tree.
+type StructureEvent struct {
+	Kind    StructureKind
+	Leaf    bool // Whether the split or underflowing node is a leaf, always true for reinsertions
+	Entries int  // Children of the split nodes, or entries orphaned or reinserted
+}
+
+// Observer is notified of the queries and structural changes of a tree. Its methods are called synchronously, with
+// the tree locked: they must be fast and must not call the tree.
+type Observer interface {
+	ObserveQuery(QueryStats)
+	ObserveStructure(StructureEvent)
+}
+
+// WithObserver sets the observer of the tree.
+func WithObserver(o Observer) Option {
+	return func(t *RTree) error {
+		if o == nil {
+			return errors.New("observer is nil")
+		}
+		t.observer = o
+		return nil
+	}
+}
+
+// startQuery returns the time the query started, or the zero time when nothing observes the tree.
+func (t *RTree) startQuery() time.Time {
+	if t.observer == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// observeQuery reports the stats of a query started at start.
+func (t *RTree) observeQuery(stats QueryStats, start time.Time) {
+	if t.observer == nil {
+		return
+	}
+	stats.Duration = time.Since(start)
+	t.observer.ObserveQuery(stats)
+}
+
+// observeStructure reports a structural change.
+func (t *RTree) observeStructure(kind StructureKind, leaf bool, entries int) {
+	if t.observer == nil {
+		return
+	}
+	t.observer.ObserveStructure(StructureEvent{Kind: kind, Leaf: leaf, Entries: entries})
+}
@@ -0,0 +1,111 @@
+package gortree_test
+
+import (
+	"testing"
+
+	"github.com/lambertmata/gortree"
+)
+
+type recorder struct {
+	queries   []gortree.QueryStats
+	structure map[gortree.StructureKind]int
+}
+
+func (r *recorder) ObserveQuery(s gortree.QueryStats) {
+	r.queries = append(r.queries, s)
+}
+
+func (r *recorder) ObserveStructure(e gortree.StructureEvent) {
+	if r.structure == nil {
+		r.structure = make(map[gortree.StructureKind]int)
+	}
+	r.structure[e.Kind]++
+}
+
+func TestRTree_Observer_Queries(t *testing.T) {
+
+	rec := &recorder{}
+	rt, err := gortree.NewRTreeWithOptions(gortree.WithObserver(rec))
+	if err != nil {
+		t.Fatalf("NewRTreeWithOptions: %v", err)
+	}
+
+	for _, l := range randomLocations(500, 2) {
+		rt.Insert(l)
+	}
+
+	results := rt.Query(*gortree.NewRect(0, 0, 30, 30))
+	rt.Nearest(gortree.Point{0, 0}, 5)
+	rt.QueryRadius(gortree.Point{0, 0}, 10)
+	rt.QueryAt(*WholeWorld, epoch)
+
+	if len(rec.queries) != 4 {
+		t.Fatalf("Expected 4 queries observed, got %d", len(rec.queries))
+	}
+
+	ops := []gortree.QueryOp{gortree.OpQuery, gortree.OpNearest, gortree.OpQueryRadius, gortree.OpQueryDuring}
+	for i, s := range rec.queries {
+		if s.Op != ops[i] {
+			t.Errorf("Expected op %s, got %s", ops[i], s.Op)
+		}
+		if s.NodesVisited < s.LeavesVisited || s.LeavesVisited == 0 || s.EntriesTested < s.Results {
+			t.Errorf("Inconsistent stats for %s: %+v", s.Op, s)
+		}
+	}
+
+	q := rec.queries[0]
+	if q.Results != len(results) {
+		t.Errorf("Expected %d results, got %d", len(results), q.Results)
+	}
+
+	// A small window prunes most of the tree
+	if q.EntriesTested >= 500 || q.Duration < 0 {
+		t.Errorf("Expected a pruned query, got %+v", q)
+	}
+
+	if nearest := rec.queries[1]; nearest.Results != 5 {
+		t.Errorf("Expected 5 nearest results, got %d", nearest.Results)
+	}
+
+	if full := rec.queries[3]; full.Results != 500 || full.EntriesTested != 500 {
+		t.Errorf("Expected the whole tree tested, got %+v", full)
+	}
+}
+
+func TestRTree_Observer_Structure(t *testing.T) {
+
+	for _, opt := range []gortree.Option{gortree.WithHilbert(*WholeWorld), gortree.WithMinMax(2, 4)} {
+
+		rec := &recorder{}
+		rt, err := gortree.NewRTreeWithOptions(opt, gortree.WithObserver(rec))
+		if err != nil {
+			t.Fatalf("NewRTreeWithOptions: %v", err)
+		}
+
+		locations := randomLocations(200, 4)
+		for _, l := range locations {
+			rt.Insert(l)
+		}
+
+		if rec.structure[gortree.StructureSplit] == 0 {
+			t.Errorf("%s: expected splits", rt.Mode())
+		}
+		if rec.structure[gortree.StructureUnderflow] != 0 {
+			t.Errorf("%s: expected no underflow on inserts", rt.Mode())
+		}
+
+		for _, l := range locations[:150] {
+			if err := rt.Delete(l); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+		}
+
+		if rec.structure[gortree.StructureUnderflow] == 0 || rec.structure[gortree.StructureReinsert] == 0 {
+			t.Errorf("%s: expected underflows and reinsertions, got %v", rt.Mode(), rec.structure)
+		}
+	}
+
+	if _, err := gortree.NewRTreeWithOptions(gortree.WithObserver(nil)); err == nil {
+		t.Errorf("Expected error for nil observer")
+	}
+}
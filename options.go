@@ -0,0 +1,95 @@
+package gortree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Mode selects the algorithm used to organize the tree entries.
+type Mode int
+
+const (
+	// ModeQuadratic is Guttman's R-tree with quadratic split.
+	ModeQuadratic Mode = iota
+	// ModeHilbert is the Hilbert R-tree, which keeps entries ordered by the Hilbert value of their center and handles
+	// overflows with 2-to-3 deferred splitting.
+	ModeHilbert
+)
+
+// String returns the mode name.
+func (m Mode) String() string {
+	switch m {
+	case ModeQuadratic:
+		return "quadratic"
+	case ModeHilbert:
+		return "hilbert"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+}
+
+// Option configures an RTree.
+type Option func(*RTree) error
+
+// NewRTreeWithOptions creates an r-tree with default parameters, overridden by the given options.
+func NewRTreeWithOptions(opts ...Option) (*RTree, error) {
+	rt := NewRTree()
+
+	for _, opt := range opts {
+		if err := opt(rt); err != nil {
+			return nil, fmt.Errorf("invalid parameters: %w", err)
+		}
+	}
+
+	return rt, nil
+}
+
+// WithMinMax sets the min and max entries for each node.
+func WithMinMax(min, max int) Option {
+	return func(t *RTree) error {
+		if err := validateMinMax(min, max); err != nil {
+			return err
+		}
+		t.minEntries = min
+		t.maxEntries = max
+		return nil
+	}
+}
+
+// WithDims sets the number of dimensions of the indexed boxes. Entries implementing SpatialBox are indexed by their
+// Box, the others by their BoundingBox. Boxes with fewer dimensions are extended with zero-width dimensions at 0, those
+// with more are truncated.
+func WithDims(dims int) Option {
+	return func(t *RTree) error {
+		if dims < 1 || dims > MaxDims {
+			return fmt.Errorf("dims=%d (must satisfy 1 ≤ dims ≤ %d)", dims, MaxDims)
+		}
+		t.dims = dims
+		return nil
+	}
+}
+
+// WithClock sets the clock used to expire entries inserted with a TTL.
+func WithClock(clock Clock) Option {
+	return func(t *RTree) error {
+		if clock == nil {
+			return errors.New("clock is nil")
+		}
+		t.clock = clock
+		return nil
+	}
+}
+
+// WithHilbert selects ModeHilbert. The domain is the space mapped onto the Hilbert curve, entries centered outside of
+// it are clamped to its border. With more than two dimensions, entries are ordered by the center of their projection
+// on the first two.
+func WithHilbert(domain Rect) Option {
+	return func(t *RTree) error {
+		if domain.MaxX <= domain.MinX || domain.MaxY <= domain.MinY {
+			return errors.New("hilbert domain must have a positive width and height")
+		}
+		t.mode = ModeHilbert
+		t.domain = domain
+		return nil
+	}
+}
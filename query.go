@@ -6,6 +6,7 @@ func (t *RTree) Entries() []Spatial {
 	defer t.mu.RUnlock()
 
 	entries := make([]Spatial, 0)
+	now := t.now()
 
 	stack := NewStackFrom(t.root)
 
@@ -15,7 +16,9 @@ func (t *RTree) Entries() []Spatial {
 
 		if cur.IsLeaf {
 			for _, e := range cur.Children {
-				entries = append(entries, e.Data)
+				if !expired(e, now) {
+					entries = append(entries, e.Data)
+				}
 			}
 		} else {
 			stack.Push(cur.Children...)
@@ -28,26 +31,62 @@ func (t *RTree) Entries() []Spatial {
 
 // Query finds all items intersecting the given Rect
 func (t *RTree) Query(r Rect) []Spatial {
+	return t.QueryBox(r.Box())
+}
+
+// QueryBox finds all items intersecting the given Box. Dimensions of the tree missing from r are unconstrained.
+func (t *RTree) QueryBox(r Box) []Spatial {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.search(OpQuery, r, nil)
+}
+
+// QueryFunc finds all items intersecting the given Rect for which keep returns true. The entries are filtered during
+// the traversal, without collecting the others. A nil keep keeps all entries, like Query.
+func (t *RTree) QueryFunc(r Rect, keep func(Spatial) bool) []Spatial {
 
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
+	if keep == nil {
+		return t.search(OpQuery, r.Box(), nil)
+	}
+
+	return t.search(OpQuery, r.Box(), func(n *node) bool {
+		return n.Data == nil || keep(n.Data)
+	})
+}
+
+// search finds all items intersecting r, hiding the expired ones. When filter is not nil, it is called with both
+// internal nodes and entry nodes: branches and entries it rejects are skipped. The query is reported to the observer
+// as op. Requires t.mu held.
+func (t *RTree) search(op QueryOp, r Box, filter func(n *node) bool) []Spatial {
+
+	start := t.startQuery()
+	stats := QueryStats{Op: op}
+
 	stack := NewStackFrom(t.root)
 	results := make([]Spatial, 0)
+	now := t.now()
 
 	for !stack.Empty() {
 
 		cur, _ := stack.Pop()
+		stats.NodesVisited++
 
 		// Skip non-intersecting branches
-		if !cur.BoundingBox.Intersects(r) {
+		if !cur.BoundingBox.Intersects(r) || (filter != nil && !filter(cur)) {
 			continue
 		}
 
 		// We have a leaf, return all intersecting entries
 		if cur.IsLeaf {
+			stats.LeavesVisited++
+			stats.EntriesTested += len(cur.Children)
 			for _, e := range cur.Children {
-				if e.BoundingBox.Intersects(r) {
+				if e.BoundingBox.Intersects(r) && !expired(e, now) && (filter == nil || filter(e)) {
 					results = append(results, e.Data)
 				}
 			}
@@ -57,5 +96,8 @@ func (t *RTree) Query(r Rect) []Spatial {
 		}
 	}
 
+	stats.Results = len(results)
+	t.observeQuery(stats, start)
+
 	return results
 }
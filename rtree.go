@@ -6,6 +6,7 @@ import (
 	"math"
 	"slices"
 	"sync"
+	"sync/atomic"
 )
 
 type Spatial interface {
@@ -14,10 +15,25 @@ type Spatial interface {
 }
 
 type RTree struct {
+	id         uint64 // Unique among the trees of the process, orders the locks of joins
 	mu         sync.RWMutex
 	root       *node
 	maxEntries int
 	minEntries int
+	dims       int
+	size       int
+	mode       Mode
+	domain     Rect // Space mapped onto the Hilbert curve in ModeHilbert
+	clock      Clock
+	observer   Observer              // Notified of queries and structural changes, nil when unobserved
+	value      func(Spatial) float64 // Value of the entries aggregated by the nodes, nil when not aggregating
+	version    uint64                // Incremented by every write, so that NearestIter notices changes between steps
+
+	watchMu   sync.Mutex // Acquired after mu, never held while delivering
+	watchers  []*watcher
+	watching  atomic.Int32
+	pending   []Event       // Events of the current write, delivered on unlock
+	delivered chan struct{} // Closed once the events of the last write with events are delivered, nil before
 }
 
 const (
@@ -25,12 +41,19 @@ const (
 	MaxEntries = 4
 )
 
+// treeIDs numbers the trees as they are created.
+var treeIDs atomic.Uint64
+
 func NewRTree() *RTree {
 	return &RTree{
+		id:         treeIDs.Add(1),
 		maxEntries: MaxEntries,
 		minEntries: MinEntries,
+		dims:       2,
+		clock:      systemClock{},
 		root: &node{
 			IsLeaf: true,
+			Expiry: noExpiry,
 		},
 	}
 }
@@ -45,15 +68,7 @@ func validateMinMax(min, max int) error {
 
 // NewRTreeWithMinMax create r-tree with min and max entries parameters.
 func NewRTreeWithMinMax(min, max int) (*RTree, error) {
-	rt := NewRTree()
-
-	if err := validateMinMax(min, max); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	rt.minEntries = min
-	rt.maxEntries = max
-	return rt, nil
+	return NewRTreeWithOptions(WithMinMax(min, max))
 }
 
 // Min the min entries for each node.
@@ -66,8 +81,25 @@ func (t *RTree) Max() int {
 	return t.maxEntries
 }
 
+// Len the number of entries stored in the tree, including expired ones not reaped yet.
+func (t *RTree) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.size
+}
+
+// Dims the number of dimensions of the indexed boxes.
+func (t *RTree) Dims() int {
+	return t.dims
+}
+
+// Mode the algorithm used to organize the entries.
+func (t *RTree) Mode() Mode {
+	return t.mode
+}
+
 // chooseLeaf selects the best node for inserting a new entry.
-func (t *RTree) chooseLeaf(n *node, boundingBox Rect) *node {
+func (t *RTree) chooseLeaf(n *node, boundingBox Box) *node {
 
 	// The tree is descended until a leaf is reached, by selecting the child node which requires the least enlargement
 	// to contain rect.
@@ -101,9 +133,17 @@ func (t *RTree) chooseLeaf(n *node, boundingBox Rect) *node {
 	return t.chooseLeaf(bestNode, boundingBox)
 }
 
-// updateNodeMBR Using current entries MBRs it updated the node BoundingBox.
+// updateNodeMBR Using current entries MBRs it updated the node BoundingBox, along with the other summaries of the
+// subtree entries: largest Hilbert value, validity, earliest expiry, count, aggregated values, highest score and
+// keywords signature.
 func (t *RTree) updateNodeMBR(n *node) {
 	n.BoundingBox = computeNodesMBR(n.Children)
+	n.LHV = computeNodesLHV(n.Children)
+	n.Validity = computeNodesValidity(n.Children)
+	n.Expiry = computeNodesExpiry(n.Children)
+	n.Summary = computeNodesSummary(n.Children)
+	n.MaxScore = computeNodesMaxScore(n.Children)
+	n.Keywords = computeNodesSignature(n.Children)
 }
 
 // updateMBRsUpward updates MBRs starting from node up to the root.
@@ -140,7 +180,7 @@ func (t *RTree) adjustTree(n *node, splitNode *node) {
 		t.root = newRoot
 
 		// Update the BoundingBox of the new root
-		newRoot.BoundingBox = computeNodesMBR(newRoot.Children)
+		t.updateNodeMBR(newRoot)
 
 		return
 	}
@@ -151,7 +191,7 @@ func (t *RTree) adjustTree(n *node, splitNode *node) {
 	parent := n.Parent
 
 	// Update the BoundingBox of the original node
-	n.BoundingBox = computeNodesMBR(n.Children)
+	t.updateNodeMBR(n)
 
 	// Add splitNode to parent
 	parent.Children = append(parent.Children, splitNode)
@@ -173,16 +213,42 @@ func (t *RTree) Insert(data Spatial) {
 	}
 
 	t.mu.Lock()
-	defer t.mu.Unlock()
+	defer t.unlock()
 
-	t.insertEntry(data)
+	e := t.insertEntry(data)
+	t.size++
+
+	t.notify(EventInsert, data, Box{}, e.BoundingBox)
 }
 
 // insertEntry requires t.mu held for writing.
-func (t *RTree) insertEntry(data Spatial) {
+func (t *RTree) insertEntry(data Spatial) *node {
+	e := t.newEntry(data)
+	t.insertNode(e)
+	return e
+}
+
+// newEntry creates the entry node for data.
+func (t *RTree) newEntry(data Spatial) *node {
 
-	// Create the new entry node
-	e := newLeafNode(data)
+	e := newLeafNode(data, t.dims)
+	e.Summary = t.summaryOf(data)
+
+	if t.mode == ModeHilbert {
+		e.LHV = t.hilbertValue(e.BoundingBox)
+	}
+
+	return e
+}
+
+// insertNode adds an entry node to the tree, either a new one or one orphaned by condenseTree. Requires t.mu held for
+// writing.
+func (t *RTree) insertNode(e *node) {
+
+	if t.mode == ModeHilbert {
+		t.insertHilbert(e)
+		return
+	}
 
 	// Find the best leaf node to insert the new entry node.
 	leaf := t.chooseLeaf(t.root, e.BoundingBox)
@@ -229,8 +295,8 @@ func (t *RTree) pickSeeds(entryNodes []*node) [2]*node {
 }
 
 // computeNodesMBR returns the minimum bounding rectangle containing all nodes.
-func computeNodesMBR(nodes []*node) Rect {
-	var mbr Rect
+func computeNodesMBR(nodes []*node) Box {
+	var mbr Box
 	if len(nodes) == 0 {
 		return mbr
 	}
@@ -297,6 +363,12 @@ func (t *RTree) splitNode(n *node) *node {
 	t.adjustEntriesParent(n)
 	t.adjustEntriesParent(b)
 
+	// Refresh the summaries of both groups
+	t.updateNodeMBR(n)
+	t.updateNodeMBR(b)
+
+	t.observeStructure(StructureSplit, n.IsLeaf, len(n.Children)+len(b.Children))
+
 	// Return b as the new split node
 	return b
 }
@@ -400,39 +472,54 @@ func (t *RTree) collectLeafNodes(n *node) []*node {
 }
 
 // CondenseTree handles nodes with too few entries after deletion. It removes underflowing nodes and returns their
-// entries so they can be reinserted.
-func (t *RTree) condenseTree(n *node) ([]*node, error) {
+// entries so they can be reinserted. The given nodes, where the deletions took place, must all be at the same depth, so
+// that a single pass moving up one level at a time handles them all.
+func (t *RTree) condenseTree(nodes ...*node) ([]*node, error) {
 
 	var orphans []*node // Stores the node that will need to be reinserted
-	cur := n            // The node where the delete took place
+	level := nodes      // The nodes where the deletes took place
 
-	// Repeat the process from current node all the way up to the root
-	for cur.Parent != nil {
+	// Repeat the process from current level all the way up to the root
+	for len(level) > 0 {
 
-		parent := cur.Parent
+		var parents []*node
 
-		// Check if the current node has too few entries
-		if t.nodeUnderflowing(cur) {
+		for _, cur := range level {
 
-			if err := t.removeNodeFromParent(parent, cur); err != nil {
-				return nil, fmt.Errorf("condenseTree: %w", err)
+			parent := cur.Parent
+			if parent == nil {
+				continue
 			}
 
-			// Collect all leaf nodes that need to be reinserted
-			if cur.IsLeaf {
-				// Collect all entries
-				orphans = append(orphans, cur.Children...)
+			// Check if the current node has too few entries
+			if t.nodeUnderflowing(cur) {
+
+				t.observeStructure(StructureUnderflow, cur.IsLeaf, len(cur.Children))
+
+				if err := t.removeNodeFromParent(parent, cur); err != nil {
+					return nil, fmt.Errorf("condenseTree: %w", err)
+				}
+
+				// Collect all leaf nodes that need to be reinserted
+				if cur.IsLeaf {
+					// Collect all entries
+					orphans = append(orphans, cur.Children...)
+				} else {
+					// Collect all entries descending the subtree
+					orphans = append(orphans, t.collectLeafNodes(cur)...)
+				}
+
 			} else {
-				// Collect all entries descending the subtree
-				orphans = append(orphans, t.collectLeafNodes(cur)...)
+				// Just update the current node bounding box
+				t.updateNodeMBR(cur)
 			}
 
-		} else {
-			// Just update the current node bounding box
-			t.updateNodeMBR(cur)
+			if !slices.Contains(parents, parent) {
+				parents = append(parents, parent)
+			}
 		}
 
-		cur = parent
+		level = parents
 	}
 
 	// Finally adjust the root bounding box as well
@@ -442,6 +529,53 @@ func (t *RTree) condenseTree(n *node) ([]*node, error) {
 
 }
 
+// condenseAndReinsert condenses the tree after deletions from nodes and reinserts the orphaned entries. Requires t.mu
+// held for writing.
+func (t *RTree) condenseAndReinsert(nodes ...*node) error {
+
+	// Handle the underflow after deletion and collect orphaned entries
+	orphans, err := t.condenseTree(nodes...)
+	if err != nil {
+		return err
+	}
+
+	// When every subtree has been removed start over from an empty leaf, so that entries are never added to an
+	// internal node
+	if !t.root.IsLeaf && len(t.root.Children) == 0 {
+		t.root = &node{IsLeaf: true}
+		t.updateNodeMBR(t.root)
+	}
+
+	// Reinsert the orphaned entries
+	for _, o := range orphans {
+		t.insertNode(o)
+	}
+
+	if len(orphans) > 0 {
+		t.observeStructure(StructureReinsert, true, len(orphans))
+	}
+
+	// Make the leaf the new root if it's the only one child
+	for !t.root.IsLeaf && len(t.root.Children) == 1 {
+		t.root = t.root.Children[0]
+		t.root.Parent = nil
+	}
+
+	return nil
+}
+
+// errNotFound is returned when deleting or updating an entry missing from the tree.
+var errNotFound = errors.New("node to delete not found")
+
+// contains tells whether the tree has an entry with the ID of data.
+func (t *RTree) contains(data Spatial) bool {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.findLeaf(data) != nil
+}
+
 // findLeaf starting from the root it searches the given data by ID, narrowing down the results using the bounding box.
 func (t *RTree) findLeaf(data Spatial) *node {
 
@@ -451,7 +585,7 @@ func (t *RTree) findLeaf(data Spatial) *node {
 
 	stack := NewStackFrom(t.root)
 
-	bbox := data.BoundingBox()
+	bbox := boxOf(data, t.dims)
 	id := data.ID()
 
 	// Traverse the tree starting from the root
@@ -479,6 +613,27 @@ func (t *RTree) findLeaf(data Spatial) *node {
 	return nil
 }
 
+// removeEntry finds the entry node by the data ID and removes it from its leaf, without condensing the tree. It
+// returns the removed entry node and its leaf, or nil when not found. Requires t.mu held for writing.
+func (t *RTree) removeEntry(data Spatial) (*node, *node) {
+
+	// Find the leaf node which contains data ID
+	leaf := t.findLeaf(data)
+
+	if leaf == nil {
+		return nil, nil
+	}
+
+	// Remove the entry from the leaf node
+	idx := slices.IndexFunc(leaf.Children, func(entry *node) bool {
+		return entry.Data != nil && entry.Data.ID() == data.ID()
+	})
+	entry := leaf.Children[idx]
+	leaf.Children = slices.Delete(leaf.Children, idx, idx+1)
+
+	return entry, leaf
+}
+
 // Delete deletes the entry from the tree by the data ID.
 func (t *RTree) Delete(data Spatial) error {
 
@@ -487,36 +642,83 @@ func (t *RTree) Delete(data Spatial) error {
 	}
 
 	t.mu.Lock()
-	defer t.mu.Unlock()
+	defer t.unlock()
 
-	// Find the leaf node which contains data ID
-	leaf := t.findLeaf(data)
+	entry, err := t.deleteEntry(data)
+	if entry != nil {
+		t.notify(EventDelete, entry.Data, entry.BoundingBox, Box{})
+	}
 
-	if leaf == nil {
-		return errors.New("node to delete not found")
+	return err
+}
+
+// deleteEntry removes the entry by the data ID and condenses the tree. It returns the removed entry node, or nil when
+// not found. Requires t.mu held for writing.
+func (t *RTree) deleteEntry(data Spatial) (*node, error) {
+
+	// Find and remove the entry node
+	entry, leaf := t.removeEntry(data)
+
+	if entry == nil {
+		return nil, errNotFound
 	}
 
-	// Remove the entry from the leaf node
-	leaf.Children = slices.DeleteFunc(leaf.Children, func(entry *node) bool {
-		return entry.Data != nil && entry.Data.ID() == data.ID()
-	})
+	t.size--
 
-	// Handle the underflow after deletion and collect orphaned entries
-	orphans, err := t.condenseTree(leaf)
-	if err != nil {
-		return fmt.Errorf("delete %s: %w", data.ID(), err)
+	// Handle the underflow and reinsert the orphaned entries
+	if err := t.condenseAndReinsert(leaf); err != nil {
+		return entry, fmt.Errorf("delete %s: %w", data.ID(), err)
 	}
 
-	// Reinsert the orphaned entries
-	for _, o := range orphans {
-		t.insertEntry(o.Data)
+	return entry, nil
+}
+
+// deleteNode removes the given entry node, rather than the first entry with its ID, and condenses the tree. Requires
+// t.mu held for writing.
+func (t *RTree) deleteNode(e *node) error {
+
+	leaf := e.Parent
+	if err := t.removeNodeFromParent(leaf, e); err != nil {
+		return err
 	}
 
-	// Make the leaf the new root if it's the only one child
-	if !t.root.IsLeaf && len(t.root.Children) == 1 {
-		t.root = t.root.Children[0]
-		t.root.Parent = nil
+	t.size--
+
+	return t.condenseAndReinsert(leaf)
+}
+
+// Update moves an entry: the entry with the ID of old is replaced by data, whose bounding box may differ. An entry
+// inserted with a TTL keeps its expiry.
+func (t *RTree) Update(old, data Spatial) error {
+
+	if old == nil || data == nil {
+		return errors.New("data is nil")
 	}
 
-	return nil
+	t.mu.Lock()
+	defer t.unlock()
+
+	_, _, err := t.updateEntry(old, data)
+
+	return err
+}
+
+// updateEntry replaces the entry with the ID of old with data. It returns the removed and the inserted entry nodes.
+// Requires t.mu held for writing.
+func (t *RTree) updateEntry(old, data Spatial) (*node, *node, error) {
+
+	removed, err := t.deleteEntry(old)
+	if err != nil {
+		return nil, nil, fmt.Errorf("update %s: %w", old.ID(), err)
+	}
+
+	e := t.newEntry(data)
+	e.Expiry = removed.Expiry
+
+	t.insertNode(e)
+	t.size++
+
+	t.notify(EventUpdate, data, removed.BoundingBox, e.BoundingBox)
+
+	return removed, e, nil
 }
@@ -155,6 +155,39 @@ func TestRTree_Query(t *testing.T) {
 
 }
 
+func TestRTree_QueryFunc(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	locations := randomLocations(1000, 12)
+	rt.InsertBatch(toSpatial(locations))
+
+	east := func(item gortree.Spatial) bool {
+		return item.(*Location).Coordinates[0] > 0
+	}
+
+	window := *gortree.NewRect(-50, -50, 50, 50)
+	found := rt.QueryFunc(window, east)
+	for _, item := range found {
+		if !east(item) {
+			t.Errorf("Expected only eastern entries, got %s", item.ID())
+		}
+	}
+
+	expected := 0
+	for _, item := range rt.Query(window) {
+		if east(item) {
+			expected++
+		}
+	}
+	if expected == 0 || len(found) != expected {
+		t.Errorf("Expected %d entries, got %d", expected, len(found))
+	}
+
+	if got := rt.QueryFunc(*WholeWorld, nil); len(got) != len(locations) {
+		t.Errorf("Expected all %d entries with a nil filter, got %d", len(locations), len(got))
+	}
+}
+
 func TestRTree_Delete(t *testing.T) {
 	rt := gortree.NewRTree()
 	for _, location := range cityLocations {
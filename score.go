@@ -0,0 +1,81 @@
+package gortree
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Scored is implemented by entries ranked by a score, such as their popularity, by TopK. Entries not implementing
+// Scored have a score of zero.
+type Scored interface {
+	Spatial
+	Score() float64
+}
+
+// scoreOf returns the score of data.
+func scoreOf(data Spatial) float64 {
+	if scored, ok := data.(Scored); ok {
+		return scored.Score()
+	}
+	return 0
+}
+
+// computeNodesMaxScore returns the highest score among nodes.
+func computeNodesMaxScore(nodes []*node) float64 {
+	score := math.Inf(-1)
+	for _, n := range nodes {
+		score = max(score, n.MaxScore)
+	}
+	return score
+}
+
+// TopK finds the k entries intersecting r with the highest scores, ordered by decreasing score. Nodes are visited by
+// decreasing maximum score in their subtree, so that the search stops as soon as k entries are found, without
+// visiting the subtrees whose entries all score lower. Fewer than k entries are returned when fewer intersect r.
+func (t *RTree) TopK(r Rect, k int) []Spatial {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	results := make([]Spatial, 0, max(k, 0))
+	if k <= 0 {
+		return results
+	}
+
+	start := t.startQuery()
+	stats := QueryStats{Op: OpTopK}
+
+	query := r.Box()
+	now := t.now()
+
+	// Best-first search on the negated scores: an entry popped scores higher than everything left in the queue
+	queue := &distanceQueue{{node: t.root, dist: -t.root.MaxScore}}
+
+	for queue.Len() > 0 && len(results) < k {
+
+		cur := heap.Pop(queue).(distanceItem)
+
+		if cur.node.Data != nil {
+			results = append(results, cur.node.Data)
+			continue
+		}
+
+		stats.NodesVisited++
+		if cur.node.IsLeaf {
+			stats.LeavesVisited++
+			stats.EntriesTested += len(cur.node.Children)
+		}
+
+		for _, child := range cur.node.Children {
+			if !child.BoundingBox.Intersects(query) || (child.Data != nil && expired(child, now)) {
+				continue
+			}
+			heap.Push(queue, distanceItem{node: child, dist: -child.MaxScore})
+		}
+	}
+
+	stats.Results = len(results)
+	t.observeQuery(stats, start)
+
+	return results
+}
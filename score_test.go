@@ -0,0 +1,123 @@
+package gortree_test
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+)
+
+// POI is a location ranked by popularity.
+type POI struct {
+	*Location
+	Popularity float64
+}
+
+func (p *POI) Score() float64 {
+	return p.Popularity
+}
+
+// bruteForceTopK returns the IDs of the k most popular pois intersecting r.
+func bruteForceTopK(pois []*POI, r gortree.Rect, k int) []string {
+
+	var matching []*POI
+	for _, p := range pois {
+		if bbox := p.BoundingBox(); bbox.Intersects(r) {
+			matching = append(matching, p)
+		}
+	}
+
+	slices.SortFunc(matching, func(a, b *POI) int { return cmp.Compare(b.Popularity, a.Popularity) })
+
+	ids := make([]string, 0, k)
+	for _, p := range matching[:min(k, len(matching))] {
+		ids = append(ids, p.ID())
+	}
+	return ids
+}
+
+func TestRTree_TopK(t *testing.T) {
+
+	for _, mode := range []gortree.Mode{gortree.ModeQuadratic, gortree.ModeHilbert} {
+
+		rec := &recorder{}
+		opts := []gortree.Option{gortree.WithMinMax(4, 16), gortree.WithObserver(rec)}
+		if mode == gortree.ModeHilbert {
+			opts = append(opts, gortree.WithHilbert(*WholeWorld))
+		}
+
+		rt, err := gortree.NewRTreeWithOptions(opts...)
+		if err != nil {
+			t.Fatalf("NewRTreeWithOptions: %v", err)
+		}
+
+		rnd := rand.New(rand.NewPCG(13, 14))
+
+		var pois []*POI
+		for _, l := range randomLocations(3000, 15) {
+			p := &POI{Location: l, Popularity: rnd.Float64() * 1000}
+			pois = append(pois, p)
+			rt.Insert(p)
+		}
+
+		for _, p := range pois[:500] {
+			if err := rt.Delete(p); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+		}
+		pois = pois[500:]
+
+		if err := rt.Validate(); err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+
+		for i := 0; i < 30; i++ {
+
+			x, y := rnd.Float64()*360-180, rnd.Float64()*180-90
+			window := *gortree.NewRect(x, y, x+rnd.Float64()*120, y+rnd.Float64()*60)
+			k := 1 + rnd.IntN(20)
+
+			var got []string
+			for _, item := range rt.TopK(window, k) {
+				got = append(got, item.ID())
+			}
+
+			if want := bruteForceTopK(pois, window, k); !slices.Equal(got, want) {
+				t.Fatalf("mode %v: TopK(%v, %d) = %v, want %v", mode, window, k, got, want)
+			}
+		}
+
+		// Subtrees scoring too low for the top 10 aren't visited
+		rec.queries = nil
+		rt.TopK(*WholeWorld, 10)
+
+		if stats := rec.queries[0]; stats.Op != gortree.OpTopK || stats.EntriesTested >= len(pois)/4 {
+			t.Errorf("mode %v: TopK(world, 10) stats %+v, want few entries tested", mode, stats)
+		}
+
+		if got := rt.TopK(*WholeWorld, 0); len(got) != 0 {
+			t.Errorf("mode %v: TopK(k=0) = %d entries, want none", mode, len(got))
+		}
+	}
+}
+
+func TestRTree_TopK_Unscored(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	for _, location := range cityLocations {
+		rt.Insert(&location)
+	}
+	rt.Insert(&POI{Location: &Location{Name: "popular", Coordinates: [2]float64{9, 45}}, Popularity: 5})
+	rt.Insert(&POI{Location: &Location{Name: "unpopular", Coordinates: [2]float64{9, 45}}, Popularity: -5})
+
+	got := rt.TopK(*WholeWorld, len(cityLocations)+2)
+
+	if len(got) != len(cityLocations)+2 {
+		t.Fatalf("Expected %d entries, got %d", len(cityLocations)+2, len(got))
+	}
+	if got[0].ID() != "popular" || got[len(got)-1].ID() != "unpopular" {
+		t.Errorf("Expected popular first and unpopular last, the others scoring zero, got %v", ids(got))
+	}
+}
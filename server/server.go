@@ -0,0 +1,391 @@
+// Package server exposes a gortree.RTree over HTTP, with JSON requests and responses. Entries are GeoJSON features.
+//
+// Endpoints:
+//
+//	POST   /entries                                  insert the GeoJSON feature in the body, which must have an id
+//	DELETE /entries/{id}                             delete the entry
+//	GET    /query/bbox?bbox=minx,miny,maxx,maxy      entries intersecting the box
+//	GET    /query/radius?point=x,y&radius=r          entries within distance r of the point, ordered by distance
+//	GET    /query/nearest?point=x,y&k=n              n entries nearest to the point, ordered by distance
+//	GET    /stats                                    statistics of the tree
+//
+// Queries return a FeatureCollection with a truncated member, true when results have been dropped to honor the limit
+// parameter, or Options.MaxResults. Errors are returned as {"error": "message"} with a 4xx or 5xx status.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/geojson"
+	"github.com/lambertmata/gortree/geom"
+)
+
+// Options configures the server. Zero values are replaced by the defaults.
+type Options struct {
+	MaxResults      int           // Results returned by a query at most, 1000 by default
+	MaxBodyBytes    int64         // Size of a request body at most, 1 MiB by default
+	ShutdownTimeout time.Duration // Time given to in-flight requests on shutdown, 10 seconds by default
+}
+
+// Server serves the queries and updates of a tree. It must be the only writer of the tree, as it tracks the entries
+// by ID to delete them.
+type Server struct {
+	tree *gortree.RTree
+	opts Options
+	mux  *http.ServeMux
+
+	mu      sync.Mutex
+	entries map[string]gortree.Spatial
+}
+
+// New creates a server for the tree, which may already hold entries.
+func New(rt *gortree.RTree, opts Options) *Server {
+
+	if opts.MaxResults <= 0 {
+		opts.MaxResults = 1000
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = 1 << 20
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = 10 * time.Second
+	}
+
+	s := &Server{
+		tree:    rt,
+		opts:    opts,
+		mux:     http.NewServeMux(),
+		entries: make(map[string]gortree.Spatial),
+	}
+
+	for _, e := range rt.Entries() {
+		s.entries[e.ID()] = e
+	}
+
+	s.mux.HandleFunc("POST /entries", s.insert)
+	s.mux.HandleFunc("DELETE /entries/{id}", s.delete)
+	s.mux.HandleFunc("GET /query/bbox", s.queryBBox)
+	s.mux.HandleFunc("GET /query/radius", s.queryRadius)
+	s.mux.HandleFunc("GET /query/nearest", s.queryNearest)
+	s.mux.HandleFunc("GET /stats", s.stats)
+
+	return s
+}
+
+// ServeHTTP dispatches the request to its endpoint.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe listens on addr and serves requests until ctx is canceled, then shuts down gracefully.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves requests on the listener until ctx is canceled. On cancellation it stops accepting connections and
+// waits up to Options.ShutdownTimeout for in-flight requests to complete. It returns nil after a graceful shutdown.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.opts.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// writeJSON writes v as the JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes the error message as the JSON response with the given status.
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+// insert adds the feature in the request body.
+func (s *Server) insert(w http.ResponseWriter, r *http.Request) {
+
+	f := &geojson.Feature{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.opts.MaxBodyBytes)).Decode(f); err != nil {
+		if maxErr := (*http.MaxBytesError)(nil); errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, "body larger than %d bytes", maxErr.Limit)
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid feature: %v", err)
+		return
+	}
+
+	if f.ID() == "" {
+		writeError(w, http.StatusBadRequest, "feature has no id")
+		return
+	}
+
+	if err := checkGeometry(f.Geometry); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid feature %s: %v", f.ID(), err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[f.ID()]; ok {
+		writeError(w, http.StatusConflict, "entry %s already exists", f.ID())
+		return
+	}
+
+	s.tree.Insert(f)
+	s.entries[f.ID()] = f
+
+	writeJSON(w, http.StatusCreated, f)
+}
+
+// checkGeometry rejects empty geometries and coordinates that are not finite.
+func checkGeometry(g geom.Geometry) error {
+
+	if g == nil {
+		return errors.New("missing geometry")
+	}
+
+	b := g.Bounds()
+	for _, v := range []float64{b.MinX, b.MinY, b.MaxX, b.MaxY} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return errors.New("empty geometry or coordinates not finite")
+		}
+	}
+
+	return nil
+}
+
+// delete removes the entry by ID.
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "entry %s not found", id)
+		return
+	}
+
+	if err := s.tree.Delete(e); err != nil {
+		writeError(w, http.StatusInternalServerError, "delete %s: %v", id, err)
+		return
+	}
+	delete(s.entries, id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// featureCollection is the response of the queries.
+type featureCollection struct {
+	Type      string             `json:"type"`
+	Features  []*geojson.Feature `json:"features"`
+	Truncated bool               `json:"truncated"`
+}
+
+// writeResults writes at most limit results as a FeatureCollection.
+func writeResults(w http.ResponseWriter, results []gortree.Spatial, limit int) {
+
+	response := featureCollection{Type: "FeatureCollection", Features: make([]*geojson.Feature, 0, len(results))}
+
+	if len(results) > limit {
+		results = results[:limit]
+		response.Truncated = true
+	}
+
+	for _, item := range results {
+		response.Features = append(response.Features, geojson.FromSpatial(item))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// parseFloats parses the comma separated numbers of the query parameter, which must be finite and n.
+func parseFloats(r *http.Request, name string, n int) ([]float64, error) {
+
+	param := r.URL.Query().Get(name)
+	if param == "" {
+		return nil, fmt.Errorf("missing %s parameter", name)
+	}
+
+	parts := strings.Split(param, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("%s must have %d numbers, got %q", name, n, param)
+	}
+
+	values := make([]float64, n)
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid number %q in %s", p, name)
+		}
+		values[i] = v
+	}
+
+	return values, nil
+}
+
+// parseInt parses the integer query parameter, which must be within [1, max]. Missing parameters default to max
+// unless required.
+func parseInt(r *http.Request, name string, max int, required bool) (int, error) {
+
+	param := r.URL.Query().Get(name)
+	if param == "" {
+		if required {
+			return 0, fmt.Errorf("missing %s parameter", name)
+		}
+		return max, nil
+	}
+
+	v, err := strconv.Atoi(param)
+	if err != nil || v < 1 || v > max {
+		return 0, fmt.Errorf("%s must be an integer between 1 and %d, got %q", name, max, param)
+	}
+
+	return v, nil
+}
+
+// queryBBox finds the entries intersecting the bbox parameter.
+func (s *Server) queryBBox(w http.ResponseWriter, r *http.Request) {
+
+	b, err := parseFloats(r, "bbox", 4)
+	if err == nil && (b[0] > b[2] || b[1] > b[3]) {
+		err = errors.New("bbox min must not be greater than max")
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	limit, err := parseInt(r, "limit", s.opts.MaxResults, false)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	// Entries past limit+1 are not collected, so that a broad bbox costs no more memory than the response
+	kept := 0
+	results := s.tree.QueryFunc(*gortree.NewRect(b[0], b[1], b[2], b[3]), func(gortree.Spatial) bool {
+		kept++
+		return kept <= limit+1
+	})
+
+	writeResults(w, results, limit)
+}
+
+// queryRadius finds the entries within the radius parameter of the point parameter.
+func (s *Server) queryRadius(w http.ResponseWriter, r *http.Request) {
+
+	p, err := parseFloats(r, "point", 2)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	radius, err := parseFloats(r, "radius", 1)
+	if err == nil && radius[0] < 0 {
+		err = errors.New("radius must not be negative")
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	limit, err := parseInt(r, "limit", s.opts.MaxResults, false)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	// The entries come by increasing distance, so the search stops at the radius or after limit+1 entries
+	var results []gortree.Spatial
+	for item, dist := range s.tree.NearestIter(gortree.Point(p)) {
+		if dist > radius[0] || len(results) > limit {
+			break
+		}
+		results = append(results, item)
+	}
+
+	writeResults(w, results, limit)
+}
+
+// queryNearest finds the k entries nearest to the point parameter.
+func (s *Server) queryNearest(w http.ResponseWriter, r *http.Request) {
+
+	p, err := parseFloats(r, "point", 2)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	k, err := parseInt(r, "k", s.opts.MaxResults, true)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	writeResults(w, s.tree.Nearest(gortree.Point(p), k), k)
+}
+
+// statsResponse is the response of the stats endpoint.
+type statsResponse struct {
+	Entries   int     `json:"entries"`
+	Height    int     `json:"height"`
+	Nodes     int     `json:"nodes"`
+	Leaves    int     `json:"leaves"`
+	MinFill   int     `json:"min_fill"`
+	MaxFill   int     `json:"max_fill"`
+	AvgFill   float64 `json:"avg_fill"`
+	Underfull int     `json:"underfull"`
+	Overlap   float64 `json:"overlap"`
+	Coverage  float64 `json:"coverage"`
+}
+
+// stats returns the statistics of the tree.
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, statsResponse(s.tree.Stats()))
+}
@@ -0,0 +1,281 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/server"
+)
+
+// response is the decoded response of the queries and errors.
+type response struct {
+	Features []struct {
+		ID string `json:"id"`
+	} `json:"features"`
+	Truncated bool   `json:"truncated"`
+	Error     string `json:"error"`
+}
+
+func (r response) ids() []string {
+	ids := make([]string, len(r.Features))
+	for i, f := range r.Features {
+		ids[i] = f.ID
+	}
+	return ids
+}
+
+func do(t *testing.T, ts *httptest.Server, method, path, body string) (int, response) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer res.Body.Close()
+
+	var r response
+	if res.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+			t.Fatalf("%s %s: invalid response: %v", method, path, err)
+		}
+	}
+
+	return res.StatusCode, r
+}
+
+func point(id string, x, y float64) string {
+	return fmt.Sprintf(`{"type": "Feature", "id": %q, "properties": {}, "geometry": {"type": "Point", "coordinates": [%g, %g]}}`, id, x, y)
+}
+
+func newServer(t *testing.T, opts server.Options) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(server.New(gortree.NewRTree(), opts))
+	t.Cleanup(ts.Close)
+
+	for i, city := range []struct {
+		Name string
+		X, Y float64
+	}{
+		{"genova", 8.93, 44.41},
+		{"milan", 9.19, 45.47},
+		{"rome", 12.48, 41.90},
+		{"paris", 2.35, 48.86},
+		{"london", -0.13, 51.51},
+	} {
+		if status, r := do(t, ts, "POST", "/entries", point(city.Name, city.X, city.Y)); status != http.StatusCreated {
+			t.Fatalf("Insert %d: status %d, %s", i, status, r.Error)
+		}
+	}
+
+	return ts
+}
+
+func TestServer_Queries(t *testing.T) {
+
+	ts := newServer(t, server.Options{})
+
+	testCases := []struct {
+		Name     string
+		Path     string
+		Expected []string
+		Sorted   bool
+	}{
+		{"BBox", "/query/bbox?bbox=8,44,10,46", []string{"genova", "milan"}, false},
+		{"Radius", "/query/radius?point=9,44.5&radius=0.5", []string{"genova"}, false},
+		{"Nearest", "/query/nearest?point=0,50&k=3", []string{"london", "paris", "milan"}, true},
+	}
+
+	for _, testCase := range testCases {
+
+		status, r := do(t, ts, "GET", testCase.Path, "")
+		if status != http.StatusOK {
+			t.Fatalf("%s: status %d, %s", testCase.Name, status, r.Error)
+		}
+
+		got := r.ids()
+		if !testCase.Sorted {
+			slices.Sort(got)
+		}
+		if !slices.Equal(got, testCase.Expected) || r.Truncated {
+			t.Errorf("Expected %v for %s, got %v (truncated %v)", testCase.Expected, testCase.Name, got, r.Truncated)
+		}
+	}
+}
+
+func TestServer_Limits(t *testing.T) {
+
+	ts := newServer(t, server.Options{MaxResults: 3, MaxBodyBytes: 200})
+
+	status, r := do(t, ts, "GET", "/query/bbox?bbox=-180,-90,180,90", "")
+	if status != http.StatusOK || len(r.Features) != 3 || !r.Truncated {
+		t.Errorf("Expected 3 truncated results, got %d %v", status, r)
+	}
+
+	status, r = do(t, ts, "GET", "/query/bbox?bbox=-180,-90,180,90&limit=2", "")
+	if status != http.StatusOK || len(r.Features) != 2 || !r.Truncated {
+		t.Errorf("Expected 2 truncated results, got %d %v", status, r)
+	}
+
+	status, r = do(t, ts, "GET", "/query/radius?point=9,44.5&radius=100&limit=2", "")
+	if status != http.StatusOK || !slices.Equal(r.ids(), []string{"genova", "milan"}) || !r.Truncated {
+		t.Errorf("Expected the 2 nearest truncated results, got %d %v", status, r)
+	}
+
+	// Exactly limit results are not truncated
+	status, r = do(t, ts, "GET", "/query/radius?point=9,44.5&radius=2", "")
+	if status != http.StatusOK || len(r.Features) != 2 || r.Truncated {
+		t.Errorf("Expected 2 results, got %d %v", status, r)
+	}
+	status, r = do(t, ts, "GET", "/query/bbox?bbox=8,41,13,46", "")
+	if status != http.StatusOK || len(r.Features) != 3 || r.Truncated {
+		t.Errorf("Expected 3 results, got %d %v", status, r)
+	}
+
+	if status, _ := do(t, ts, "GET", "/query/nearest?point=0,0&k=4", ""); status != http.StatusBadRequest {
+		t.Errorf("Expected bad request for k above the max results, got %d", status)
+	}
+
+	large := point("large", 0, 0)[:len(point("large", 0, 0))-1] + `, "padding": "` + strings.Repeat("x", 200) + `"}`
+	if status, _ := do(t, ts, "POST", "/entries", large); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected request entity too large, got %d", status)
+	}
+}
+
+func TestServer_Validation(t *testing.T) {
+
+	ts := newServer(t, server.Options{})
+
+	testCases := []struct {
+		Name   string
+		Method string
+		Path   string
+		Body   string
+		Status int
+	}{
+		{"Missing bbox", "GET", "/query/bbox", "", http.StatusBadRequest},
+		{"Short bbox", "GET", "/query/bbox?bbox=1,2,3", "", http.StatusBadRequest},
+		{"Inverted bbox", "GET", "/query/bbox?bbox=10,10,0,0", "", http.StatusBadRequest},
+		{"Infinite bbox", "GET", "/query/bbox?bbox=0,0,Inf,1", "", http.StatusBadRequest},
+		{"Negative radius", "GET", "/query/radius?point=0,0&radius=-1", "", http.StatusBadRequest},
+		{"Bad point", "GET", "/query/radius?point=a,0&radius=1", "", http.StatusBadRequest},
+		{"Missing k", "GET", "/query/nearest?point=0,0", "", http.StatusBadRequest},
+		{"Zero k", "GET", "/query/nearest?point=0,0&k=0", "", http.StatusBadRequest},
+		{"Bad limit", "GET", "/query/bbox?bbox=0,0,1,1&limit=x", "", http.StatusBadRequest},
+		{"Invalid JSON", "POST", "/entries", "{", http.StatusBadRequest},
+		{"Missing id", "POST", "/entries", point("", 0, 0), http.StatusBadRequest},
+		{"Empty geometry", "POST", "/entries", `{"type": "Feature", "id": "a", "geometry": {"type": "LineString", "coordinates": []}}`, http.StatusBadRequest},
+		{"Null geometry", "POST", "/entries", `{"type": "Feature", "id": "a", "geometry": null}`, http.StatusBadRequest},
+		{"Duplicate", "POST", "/entries", point("rome", 0, 0), http.StatusConflict},
+		{"Delete missing", "DELETE", "/entries/missing", "", http.StatusNotFound},
+		{"Wrong method", "PUT", "/entries", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, testCase := range testCases {
+
+		req, err := http.NewRequest(testCase.Method, ts.URL+testCase.Path, strings.NewReader(testCase.Body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", testCase.Name, err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != testCase.Status {
+			t.Errorf("Expected status %d for %s, got %d", testCase.Status, testCase.Name, res.StatusCode)
+		}
+	}
+}
+
+func TestServer_DeleteStats(t *testing.T) {
+
+	ts := newServer(t, server.Options{})
+
+	if status, r := do(t, ts, "DELETE", "/entries/milan", ""); status != http.StatusNoContent {
+		t.Fatalf("Delete: status %d, %s", status, r.Error)
+	}
+
+	if _, r := do(t, ts, "GET", "/query/bbox?bbox=8,44,10,46", ""); !slices.Equal(r.ids(), []string{"genova"}) {
+		t.Errorf("Expected only genova left, got %v", r.ids())
+	}
+
+	res, err := ts.Client().Get(ts.URL + "/stats")
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	defer res.Body.Close()
+
+	var stats struct {
+		Entries int `json:"entries"`
+		Height  int `json:"height"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&stats); err != nil {
+		t.Fatalf("Invalid stats: %v", err)
+	}
+
+	if stats.Entries != 4 || stats.Height != 2 {
+		t.Errorf("Expected 4 entries and height 2, got %+v", stats)
+	}
+
+	// The entry can be inserted again
+	if status, _ := do(t, ts, "POST", "/entries", point("milan", 9.19, 45.47)); status != http.StatusCreated {
+		t.Errorf("Expected milan inserted again, got %d", status)
+	}
+}
+
+func TestServer_Serve(t *testing.T) {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+
+	s := server.New(gortree.NewRTree(), server.Options{ShutdownTimeout: time.Second})
+	go func() {
+		errs <- s.Serve(ctx, ln)
+	}()
+
+	res, err := http.Get("http://" + ln.Addr().String() + "/stats")
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", res.StatusCode)
+	}
+
+	cancel()
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("Expected graceful shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Serve didn't return after cancel")
+	}
+
+	if _, err := http.Get("http://" + ln.Addr().String() + "/stats"); err == nil {
+		t.Errorf("Expected connection refused after shutdown")
+	}
+}
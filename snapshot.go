@@ -0,0 +1,175 @@
+package gortree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Codec converts entries to and from bytes so that they can be persisted.
+type Codec interface {
+	Encode(data Spatial) ([]byte, error)
+	Decode(b []byte) (Spatial, error)
+}
+
+const (
+	snapshotMagic   = "GRTS"
+	snapshotVersion = 1
+
+	// recordHeaderSize is the size of the length and checksum prefix of each record.
+	recordHeaderSize = 8
+	// maxRecordSize bounds the payload size, so a corrupted length can't trigger a huge allocation.
+	maxRecordSize = 64 << 20
+)
+
+var (
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	// errTornRecord is returned when a record is truncated or fails its checksum.
+	errTornRecord = errors.New("torn or corrupted record")
+)
+
+// writeRecord writes payload prefixed by its length and CRC-32C checksum.
+func writeRecord(w io.Writer, payload []byte) error {
+	var header [recordHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(header[4:8], crc32.Checksum(payload, crcTable))
+
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// readRecord reads a single record. It returns io.EOF on a clean end of input and errTornRecord when the record is
+// incomplete or its checksum doesn't match.
+func readRecord(r io.Reader) ([]byte, error) {
+
+	var header [recordHeaderSize]byte
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, errTornRecord
+		}
+		return nil, err
+	}
+
+	size := binary.LittleEndian.Uint32(header[0:4])
+	sum := binary.LittleEndian.Uint32(header[4:8])
+
+	if size > maxRecordSize {
+		return nil, errTornRecord
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, errTornRecord
+		}
+		return nil, err
+	}
+
+	if crc32.Checksum(payload, crcTable) != sum {
+		return nil, errTornRecord
+	}
+
+	return payload, nil
+}
+
+// WriteSnapshot serializes all the tree entries to w using codec.
+func (t *RTree) WriteSnapshot(w io.Writer, codec Codec) error {
+	return writeSnapshot(w, codec, t.Entries(), 0)
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot and inserts its entries into the tree with InsertBatch, so
+// that an empty tree is bulk loaded.
+func (t *RTree) ReadSnapshot(r io.Reader, codec Codec) error {
+
+	var entries []Spatial
+	if _, err := readSnapshot(r, codec, func(data Spatial) {
+		entries = append(entries, data)
+	}); err != nil {
+		return err
+	}
+
+	t.InsertBatch(entries)
+
+	return nil
+}
+
+// writeSnapshot writes the snapshot header, tagged with the last applied log sequence number, followed by one record
+// per entry.
+func writeSnapshot(w io.Writer, codec Codec, entries []Spatial, seq uint64) error {
+
+	bw := bufio.NewWriter(w)
+
+	header := make([]byte, 0, len(snapshotMagic)+1+8+8)
+	header = append(header, snapshotMagic...)
+	header = append(header, snapshotVersion)
+	header = binary.LittleEndian.AppendUint64(header, seq)
+	header = binary.LittleEndian.AppendUint64(header, uint64(len(entries)))
+
+	if err := writeRecord(bw, header); err != nil {
+		return fmt.Errorf("write snapshot header: %w", err)
+	}
+
+	for _, e := range entries {
+		payload, err := codec.Encode(e)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", e.ID(), err)
+		}
+		if err := writeRecord(bw, payload); err != nil {
+			return fmt.Errorf("write snapshot entry %s: %w", e.ID(), err)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// readSnapshot decodes a snapshot, passing every entry to apply. It returns the log sequence number the snapshot was
+// taken at.
+func readSnapshot(r io.Reader, codec Codec, apply func(Spatial)) (uint64, error) {
+
+	br := bufio.NewReader(r)
+
+	header, err := readRecord(br)
+	if err != nil {
+		return 0, fmt.Errorf("read snapshot header: %w", err)
+	}
+
+	if len(header) != len(snapshotMagic)+1+8+8 || string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return 0, errors.New("invalid snapshot header")
+	}
+
+	header = header[len(snapshotMagic):]
+	if header[0] != snapshotVersion {
+		return 0, fmt.Errorf("unsupported snapshot version %d", header[0])
+	}
+
+	seq := binary.LittleEndian.Uint64(header[1:9])
+	count := binary.LittleEndian.Uint64(header[9:17])
+
+	for i := uint64(0); i < count; i++ {
+
+		payload, err := readRecord(br)
+		if err != nil {
+			return 0, fmt.Errorf("read snapshot entry %d: %w", i, err)
+		}
+
+		data, err := codec.Decode(payload)
+		if err != nil {
+			return 0, fmt.Errorf("decode snapshot entry %d: %w", i, err)
+		}
+
+		apply(data)
+	}
+
+	return seq, nil
+}
@@ -0,0 +1,209 @@
+package gortree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Stats describes the shape of the tree, to tune the min and max entries or spot a degraded tree.
+type Stats struct {
+	Entries   int     // Entries stored, including expired ones not reaped yet
+	Height    int     // Levels of nodes, 1 when the root is a leaf
+	Nodes     int     // Internal and leaf nodes
+	Leaves    int     // Leaf nodes
+	MinFill   int     // Fewest children of a node other than the root, or of the root when it is the only node
+	MaxFill   int     // Most children of a node
+	AvgFill   float64 // Average ratio of children to max entries, root excluded
+	Underfull int     // Nodes other than the root with fewer than min entries
+	Overlap   float64 // Total area shared by sibling nodes, the lower the better
+	Coverage  float64 // Total area of the leaves
+}
+
+// Stats computes the statistics of the tree.
+func (t *RTree) Stats() Stats {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	s := Stats{Entries: t.size, MinFill: t.maxEntries}
+	fill := 0.0
+
+	level := []*node{t.root}
+	for len(level) > 0 {
+
+		s.Height++
+		var next []*node
+
+		for _, n := range level {
+
+			s.Nodes++
+			s.MaxFill = max(s.MaxFill, len(n.Children))
+
+			if n != t.root {
+				s.MinFill = min(s.MinFill, len(n.Children))
+				fill += float64(len(n.Children)) / float64(t.maxEntries)
+				if len(n.Children) < t.minEntries {
+					s.Underfull++
+				}
+			}
+
+			if n.IsLeaf {
+				s.Leaves++
+				s.Coverage += n.BoundingBox.Area()
+				continue
+			}
+
+			s.Overlap += overlap(n.Children)
+			next = append(next, n.Children...)
+		}
+
+		level = next
+	}
+
+	if s.Nodes > 1 {
+		s.AvgFill = fill / float64(s.Nodes-1)
+	} else {
+		// Only the root
+		s.MinFill = len(t.root.Children)
+		s.AvgFill = float64(len(t.root.Children)) / float64(t.maxEntries)
+	}
+
+	return s
+}
+
+// overlap returns the total area shared by each pair of nodes.
+func overlap(nodes []*node) float64 {
+
+	total := 0.0
+
+	for i := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+
+			a, b := nodes[i].BoundingBox, nodes[j].BoundingBox
+			if !a.Intersects(b) {
+				continue
+			}
+
+			shared := Box{Dims: min(a.Dims, b.Dims)}
+			for d := 0; d < shared.Dims; d++ {
+				shared.Min[d] = max(a.Min[d], b.Min[d])
+				shared.Max[d] = min(a.Max[d], b.Max[d])
+			}
+			total += shared.Area()
+		}
+	}
+
+	return total
+}
+
+// Validate checks the invariants of the tree: parent links, leaves all at the same depth, node fill between min and
+// max entries, node summaries matching their children (bounding box, Hilbert value, validity, expiry, entry count,
+// highest score and keyword signature), Hilbert ordering in ModeHilbert, and the entry count. It returns all the
+// violations found, joined, or nil.
+func (t *RTree) Validate() error {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if t.root.Parent != nil {
+		fail("root has a parent")
+	}
+	if !t.root.IsLeaf && len(t.root.Children) < 2 {
+		fail("internal root has %d children", len(t.root.Children))
+	}
+
+	entries := 0
+	leafDepth := -1
+
+	type item struct {
+		node  *node
+		depth int
+		path  string
+	}
+
+	stack := NewStackFrom(item{t.root, 0, "root"})
+
+	for !stack.Empty() {
+
+		cur, _ := stack.Pop()
+		n := cur.node
+
+		if len(n.Children) > t.maxEntries {
+			fail("%s: %d children, more than max %d", cur.path, len(n.Children), t.maxEntries)
+		}
+		if n != t.root && len(n.Children) < t.minEntries {
+			fail("%s: %d children, fewer than min %d", cur.path, len(n.Children), t.minEntries)
+		}
+
+		if len(n.Children) > 0 {
+			if mbr := computeNodesMBR(n.Children); n.BoundingBox != mbr {
+				fail("%s: bounding box %v, expected %v", cur.path, n.BoundingBox, mbr)
+			}
+			if lhv := computeNodesLHV(n.Children); n.LHV != lhv {
+				fail("%s: largest Hilbert value %d, expected %d", cur.path, n.LHV, lhv)
+			}
+			if validity := computeNodesValidity(n.Children); n.Validity != validity {
+				fail("%s: validity %v, expected %v", cur.path, n.Validity, validity)
+			}
+			if expiry := computeNodesExpiry(n.Children); n.Expiry != expiry {
+				fail("%s: expiry %d, expected %d", cur.path, n.Expiry, expiry)
+			}
+			if summary := computeNodesSummary(n.Children); n.Summary.Count != summary.Count {
+				fail("%s: %d entries counted, expected %d", cur.path, n.Summary.Count, summary.Count)
+			}
+			if score := computeNodesMaxScore(n.Children); n.MaxScore != score {
+				fail("%s: highest score %v, expected %v", cur.path, n.MaxScore, score)
+			}
+			if keywords := computeNodesSignature(n.Children); n.Keywords != keywords {
+				fail("%s: keywords signature %x, expected %x", cur.path, n.Keywords, keywords)
+			}
+		}
+
+		for i, child := range n.Children {
+
+			path := fmt.Sprintf("%s/%d", cur.path, i)
+
+			if child.Parent != n {
+				fail("%s: wrong parent", path)
+			}
+
+			if t.mode == ModeHilbert && i > 0 && child.LHV < n.Children[i-1].LHV {
+				fail("%s: largest Hilbert value %d out of order", path, child.LHV)
+			}
+
+			if n.IsLeaf {
+				if child.Data == nil || len(child.Children) > 0 {
+					fail("%s: leaf child is not an entry", path)
+				}
+				entries++
+				continue
+			}
+
+			if child.Data != nil {
+				fail("%s: entry in an internal node", path)
+				continue
+			}
+
+			stack.Push(item{child, cur.depth + 1, path})
+		}
+
+		if n.IsLeaf {
+			if leafDepth == -1 {
+				leafDepth = cur.depth
+			} else if cur.depth != leafDepth {
+				fail("%s: leaf at depth %d, expected %d", cur.path, cur.depth, leafDepth)
+			}
+		}
+	}
+
+	if entries != t.size {
+		fail("%d entries found, size is %d", entries, t.size)
+	}
+
+	return errors.Join(errs...)
+}
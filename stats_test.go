@@ -0,0 +1,91 @@
+package gortree_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lambertmata/gortree"
+)
+
+func TestRTree_Validate(t *testing.T) {
+
+	newQuadratic := func() (*gortree.RTree, error) {
+		return gortree.NewRTreeWithOptions(gortree.WithMinMax(3, 8))
+	}
+	newHilbert := func() (*gortree.RTree, error) {
+		return gortree.NewRTreeWithOptions(gortree.WithHilbert(*WholeWorld), gortree.WithMinMax(3, 8))
+	}
+
+	for name, newTree := range map[string]func() (*gortree.RTree, error){"Quadratic": newQuadratic, "Hilbert": newHilbert} {
+
+		rt, err := newTree()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		locations := randomLocations(1000, 7)
+		for _, l := range locations[:500] {
+			rt.Insert(l)
+		}
+		rt.InsertBatch(toSpatial(locations[500:]))
+
+		if err := rt.Validate(); err != nil {
+			t.Fatalf("%s: invalid tree after inserts: %v", name, err)
+		}
+
+		for _, l := range locations[:300] {
+			if err := rt.Delete(l); err != nil {
+				t.Fatalf("%s: Delete: %v", name, err)
+			}
+		}
+		rt.DeleteBatch(toSpatial(locations[300:600]))
+
+		if err := rt.Validate(); err != nil {
+			t.Fatalf("%s: invalid tree after deletes: %v", name, err)
+		}
+
+		s := rt.Stats()
+		if s.Entries != 400 || s.Underfull != 0 || s.MaxFill > 8 || s.MinFill < 3 || s.Height < 2 {
+			t.Errorf("%s: unexpected stats %+v", name, s)
+		}
+		if s.AvgFill <= 0 || s.AvgFill > 1 || s.Leaves >= s.Nodes {
+			t.Errorf("%s: unexpected fill %+v", name, s)
+		}
+	}
+}
+
+func TestRTree_Validate_Reap(t *testing.T) {
+
+	clock := &fakeClock{now: epoch}
+	rt, err := gortree.NewRTreeWithOptions(gortree.WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewRTreeWithOptions: %v", err)
+	}
+
+	for i, l := range randomLocations(300, 3) {
+		if err := rt.InsertWithTTL(l, time.Duration(i%3+1)*time.Minute); err != nil {
+			t.Fatalf("InsertWithTTL: %v", err)
+		}
+	}
+
+	clock.Advance(2 * time.Minute)
+	if _, err := rt.Reap(); err != nil {
+		t.Fatalf("Reap: %v", err)
+	}
+
+	if err := rt.Validate(); err != nil {
+		t.Errorf("Invalid tree after reap: %v", err)
+	}
+}
+
+func TestRTree_Stats_Empty(t *testing.T) {
+
+	s := gortree.NewRTree().Stats()
+	if s.Entries != 0 || s.Height != 1 || s.Nodes != 1 || s.Leaves != 1 || s.AvgFill != 0 {
+		t.Errorf("Unexpected stats for empty tree %+v", s)
+	}
+
+	if err := gortree.NewRTree().Validate(); err != nil {
+		t.Errorf("Expected empty tree to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package gortree
+
+import (
+	"math"
+	"time"
+)
+
+// Temporal is implemented by entries valid only during the [from, to) time interval. A zero from means valid since
+// always, a zero to valid forever. Entries not implementing Temporal are always valid.
+type Temporal interface {
+	Spatial
+	Validity() (from, to time.Time)
+}
+
+// interval is a [from, to) time interval in Unix nanoseconds.
+type interval struct {
+	from, to int64
+}
+
+var (
+	// always is the validity of entries not implementing Temporal.
+	always = interval{math.MinInt64, math.MaxInt64}
+	// never is the validity of nodes without entries.
+	never = interval{math.MaxInt64, math.MinInt64}
+)
+
+// newInterval converts the time interval, mapping zero times to unbounded ends.
+func newInterval(from, to time.Time) interval {
+	i := always
+	if !from.IsZero() {
+		i.from = from.UnixNano()
+	}
+	if !to.IsZero() {
+		i.to = to.UnixNano()
+	}
+	return i
+}
+
+// validityOf returns the validity interval of data.
+func validityOf(data Spatial) interval {
+	if temporal, ok := data.(Temporal); ok {
+		return newInterval(temporal.Validity())
+	}
+	return always
+}
+
+// overlaps tells whether the two intervals share at least an instant.
+func (i interval) overlaps(other interval) bool {
+	return i.from < other.to && other.from < i.to
+}
+
+// union returns the smallest interval containing both intervals.
+func (i interval) union(other interval) interval {
+	return interval{min(i.from, other.from), max(i.to, other.to)}
+}
+
+// computeNodesValidity returns the smallest interval containing the validity of all nodes.
+func computeNodesValidity(nodes []*node) interval {
+	validity := never
+	for _, n := range nodes {
+		validity = validity.union(n.Validity)
+	}
+	return validity
+}
+
+// QueryDuring finds all items intersecting the given Rect and valid at some instant of the [from, to) interval.
+// Subtrees are pruned on both their bounding box and the union of their entries validity.
+func (t *RTree) QueryDuring(r Rect, from, to time.Time) []Spatial {
+	return t.QueryBoxDuring(r.Box(), from, to)
+}
+
+// QueryBoxDuring is QueryDuring for a Box.
+func (t *RTree) QueryBoxDuring(r Box, from, to time.Time) []Spatial {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	window := newInterval(from, to)
+
+	return t.search(OpQueryDuring, r, func(n *node) bool {
+		return n.Validity.overlaps(window)
+	})
+}
+
+// QueryAt finds all items intersecting the given Rect and valid at instant at, that is the time slice of the tree
+// at that instant.
+func (t *RTree) QueryAt(r Rect, at time.Time) []Spatial {
+	return t.QueryBoxDuring(r.Box(), at, at.Add(time.Nanosecond))
+}
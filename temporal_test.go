@@ -0,0 +1,84 @@
+package gortree_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lambertmata/gortree"
+)
+
+type Asset struct {
+	Location
+	From, To time.Time
+}
+
+func (a *Asset) Validity() (time.Time, time.Time) {
+	return a.From, a.To
+}
+
+var epoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestRTree_QueryDuring(t *testing.T) {
+
+	rt := gortree.NewRTree()
+
+	// One asset per hour, each valid for two hours, walking along the diagonal
+	for i := 0; i < 48; i++ {
+		rt.Insert(&Asset{
+			Location: Location{fmt.Sprintf("asset-%d", i), [2]float64{float64(i), float64(i)}},
+			From:     epoch.Add(time.Duration(i) * time.Hour),
+			To:       epoch.Add(time.Duration(i+2) * time.Hour),
+		})
+	}
+
+	// Always valid
+	rt.Insert(&cityLocations[0])
+
+	testCases := []struct {
+		Name     string
+		Rect     gortree.Rect
+		From, To time.Time
+		Expected int
+	}{
+		{"First day", *WholeWorld, epoch, epoch.Add(24 * time.Hour), 24 + 1},
+		{"Single hour", *WholeWorld, epoch.Add(10 * time.Hour), epoch.Add(11 * time.Hour), 2 + 1},
+		{"Single hour, outside space", *gortree.NewRect(20, 20, 30, 30), epoch.Add(10 * time.Hour), epoch.Add(11 * time.Hour), 0},
+		{"Before epoch", *WholeWorld, epoch.Add(-time.Hour), epoch, 1},
+		{"Unbounded", *WholeWorld, time.Time{}, time.Time{}, 48 + 1},
+	}
+
+	for _, testCase := range testCases {
+		if got := len(rt.QueryDuring(testCase.Rect, testCase.From, testCase.To)); got != testCase.Expected {
+			t.Errorf("Expected %d entries in %s, got %d", testCase.Expected, testCase.Name, got)
+		}
+	}
+}
+
+func TestRTree_QueryAt(t *testing.T) {
+
+	rt := gortree.NewRTree()
+
+	a := &Asset{
+		Location: Location{"asset", [2]float64{1, 1}},
+		From:     epoch,
+		To:       epoch.Add(time.Hour),
+	}
+	rt.Insert(a)
+
+	if got := len(rt.QueryAt(*WholeWorld, epoch)); got != 1 {
+		t.Errorf("Expected asset to be valid at its start, got %d entries", got)
+	}
+
+	if got := len(rt.QueryAt(*WholeWorld, epoch.Add(time.Hour))); got != 0 {
+		t.Errorf("Expected asset not to be valid at its end, got %d entries", got)
+	}
+
+	if err := rt.Delete(a); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if got := len(rt.QueryAt(*WholeWorld, epoch)); got != 0 {
+		t.Errorf("Expected no entries after delete, got %d", got)
+	}
+}
@@ -0,0 +1,157 @@
+package gortree
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time to the tree, it can be replaced for deterministic tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock used by default.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// noExpiry is the expiry of entries inserted without a TTL.
+const noExpiry = math.MaxInt64
+
+// computeNodesExpiry returns the earliest expiry among nodes.
+func computeNodesExpiry(nodes []*node) int64 {
+	expiry := int64(noExpiry)
+	for _, n := range nodes {
+		expiry = min(expiry, n.Expiry)
+	}
+	return expiry
+}
+
+// now returns the current time of the tree clock in Unix nanoseconds.
+func (t *RTree) now() int64 {
+	return t.clock.Now().UnixNano()
+}
+
+// expired tells whether the entry node is expired at now.
+func expired(e *node, now int64) bool {
+	return e.Expiry <= now
+}
+
+// InsertWithTTL adds a new item to the tree that expires after ttl. Expired items are hidden from queries right away,
+// and removed from the tree by Reap.
+func (t *RTree) InsertWithTTL(data Spatial, ttl time.Duration) error {
+
+	if data == nil {
+		return errors.New("data is nil")
+	}
+
+	t.mu.Lock()
+	defer t.unlock()
+
+	e := t.newEntry(data)
+	e.Expiry = t.clock.Now().Add(ttl).UnixNano()
+
+	t.insertNode(e)
+	t.size++
+
+	t.notify(EventInsert, data, Box{}, e.BoundingBox)
+
+	return nil
+}
+
+// Reap removes all the expired entries from the tree and returns how many were removed. The expired entries are
+// removed from their leaves first, then the tree is condensed in a single pass.
+func (t *RTree) Reap() (int, error) {
+
+	t.mu.Lock()
+	defer t.unlock()
+
+	now := t.now()
+
+	// Nodes keep the earliest expiry of their subtree, skip subtrees without expired entries
+	var leaves []*node
+	stack := NewStackFrom(t.root)
+
+	for !stack.Empty() {
+
+		n, _ := stack.Pop()
+
+		if n.Expiry > now {
+			continue
+		}
+
+		if n.IsLeaf {
+			leaves = append(leaves, n)
+		} else {
+			stack.Push(n.Children...)
+		}
+	}
+
+	if len(leaves) == 0 {
+		return 0, nil
+	}
+
+	removed := 0
+	for _, leaf := range leaves {
+		before := len(leaf.Children)
+		leaf.Children = slices.DeleteFunc(leaf.Children, func(e *node) bool {
+			if !expired(e, now) {
+				return false
+			}
+			t.notify(EventDelete, e.Data, e.BoundingBox, Box{})
+			return true
+		})
+		removed += before - len(leaf.Children)
+	}
+
+	t.size -= removed
+
+	if err := t.condenseAndReinsert(leaves...); err != nil {
+		return removed, fmt.Errorf("reap: %w", err)
+	}
+
+	return removed, nil
+}
+
+// StartReaper calls Reap every interval in a background goroutine. The returned function stops the reaper and waits
+// for it to exit. The interval must be positive.
+func (t *RTree) StartReaper(interval time.Duration) (stop func(), err error) {
+
+	if interval <= 0 {
+		return nil, fmt.Errorf("reaper interval %v must be positive", interval)
+	}
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				_, _ = t.Reap()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+		})
+	}, nil
+}
@@ -0,0 +1,116 @@
+package gortree_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lambertmata/gortree"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestRTree_InsertWithTTL(t *testing.T) {
+
+	clock := &fakeClock{now: epoch}
+
+	rt, err := gortree.NewRTreeWithOptions(gortree.WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewRTreeWithOptions: %v", err)
+	}
+
+	for _, location := range cityLocations {
+		rt.Insert(&location)
+	}
+
+	// Sensor readings expiring after 1 to 10 minutes
+	for i := 0; i < 100; i++ {
+		reading := &Location{fmt.Sprintf("reading-%d", i), [2]float64{float64(i % 50), float64(i % 30)}}
+		if err := rt.InsertWithTTL(reading, time.Duration(i%10+1)*time.Minute); err != nil {
+			t.Fatalf("InsertWithTTL: %v", err)
+		}
+	}
+
+	if got := len(rt.Query(*WholeWorld)); got != len(cityLocations)+100 {
+		t.Errorf("Expected %d entries, got %d", len(cityLocations)+100, got)
+	}
+
+	clock.Advance(5 * time.Minute)
+
+	// Expired readings are hidden before being reaped
+	if got := len(rt.Query(*WholeWorld)); got != len(cityLocations)+50 {
+		t.Errorf("Expected %d entries after 5 minutes, got %d", len(cityLocations)+50, got)
+	}
+
+	if got := len(rt.Entries()); got != len(cityLocations)+50 {
+		t.Errorf("Expected %d entries after 5 minutes, got %d", len(cityLocations)+50, got)
+	}
+
+	removed, err := rt.Reap()
+	if err != nil {
+		t.Fatalf("Reap: %v", err)
+	}
+	if removed != 50 {
+		t.Errorf("Expected 50 reaped entries, got %d", removed)
+	}
+
+	clock.Advance(time.Hour)
+
+	removed, _ = rt.Reap()
+	if removed != 50 {
+		t.Errorf("Expected 50 reaped entries, got %d", removed)
+	}
+
+	if got := len(rt.Query(*WholeWorld)); got != len(cityLocations) {
+		t.Errorf("Expected %d entries after all readings expired, got %d", len(cityLocations), got)
+	}
+
+	if removed, _ = rt.Reap(); removed != 0 {
+		t.Errorf("Expected nothing to reap, got %d", removed)
+	}
+}
+
+func TestRTree_StartReaper(t *testing.T) {
+
+	rt := gortree.NewRTree()
+
+	for _, location := range cityLocations {
+		_ = rt.InsertWithTTL(&location, time.Millisecond)
+	}
+
+	stop, err := rt.StartReaper(time.Millisecond)
+	if err != nil {
+		t.Fatalf("StartReaper: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	stop()
+
+	// Stopping twice is harmless
+	stop()
+
+	if removed, _ := rt.Reap(); removed != 0 {
+		t.Errorf("Expected the reaper to remove all entries, %d left", removed)
+	}
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := rt.StartReaper(interval); err == nil {
+			t.Errorf("Expected error for interval %v", interval)
+		}
+	}
+}
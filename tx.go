@@ -0,0 +1,180 @@
+package gortree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTxDone is returned when using a transaction already committed or rolled back.
+var ErrTxDone = errors.New("transaction already committed or rolled back")
+
+// txOp is an operation buffered by a transaction. Inserts have no old entry, deletes no new one.
+type txOp struct {
+	old  Spatial
+	data Spatial
+}
+
+// Tx buffers inserts, deletes and moves, and applies them atomically on Commit. Queries within the transaction see its
+// own writes, while readers outside of it see none of them until Commit. A Tx must not be used concurrently.
+type Tx struct {
+	tree *RTree
+	ops  []txOp
+	done bool
+}
+
+// Begin starts a transaction on the tree.
+func (t *RTree) Begin() *Tx {
+	return &Tx{tree: t}
+}
+
+// Insert buffers the insertion of data.
+func (tx *Tx) Insert(data Spatial) error {
+	return tx.add(txOp{data: data}, data)
+}
+
+// Delete buffers the deletion of the entry with the ID of data.
+func (tx *Tx) Delete(data Spatial) error {
+	return tx.add(txOp{old: data}, data)
+}
+
+// Update buffers the move of the entry with the ID of old to data.
+func (tx *Tx) Update(old, data Spatial) error {
+	if old == nil {
+		return errors.New("data is nil")
+	}
+	return tx.add(txOp{old: old, data: data}, data)
+}
+
+// add buffers the operation after validating data.
+func (tx *Tx) add(op txOp, data Spatial) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	if data == nil {
+		return errors.New("data is nil")
+	}
+	tx.ops = append(tx.ops, op)
+	return nil
+}
+
+// Query finds all items intersecting the given Rect, as if the transaction was committed.
+func (tx *Tx) Query(r Rect) []Spatial {
+
+	box := r.Box()
+	dims := tx.tree.Dims()
+
+	// Replay the operations to find the entries added or removed by the transaction, by ID
+	changed := make(map[string]Spatial)
+	var order []string
+
+	for _, op := range tx.ops {
+		if op.old != nil {
+			if _, ok := changed[op.old.ID()]; !ok {
+				order = append(order, op.old.ID())
+			}
+			changed[op.old.ID()] = nil
+		}
+		if op.data != nil {
+			if _, ok := changed[op.data.ID()]; !ok {
+				order = append(order, op.data.ID())
+			}
+			changed[op.data.ID()] = op.data
+		}
+	}
+
+	results := make([]Spatial, 0)
+
+	for _, data := range tx.tree.QueryBox(box) {
+		if _, ok := changed[data.ID()]; !ok {
+			results = append(results, data)
+		}
+	}
+
+	for _, id := range order {
+		if data := changed[id]; data != nil {
+			if entryBox := boxOf(data, dims); entryBox.Intersects(box) {
+				results = append(results, data)
+			}
+		}
+	}
+
+	return results
+}
+
+// Commit applies all the operations under a single write lock. If any of them fails, those already applied are
+// undone and the tree is left unchanged, watchers included.
+func (tx *Tx) Commit() error {
+
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+
+	t := tx.tree
+
+	t.mu.Lock()
+	defer t.unlock()
+
+	events := len(t.pending)
+
+	// undo holds the inverse of each applied operation
+	var undo []func()
+
+	for _, op := range tx.ops {
+
+		var err error
+
+		switch {
+
+		case op.old == nil:
+			e := t.insertEntry(op.data)
+			t.size++
+			t.notify(EventInsert, op.data, Box{}, e.BoundingBox)
+			undo = append(undo, func() {
+				_ = t.deleteNode(e)
+			})
+
+		case op.data == nil:
+			var removed *node
+			removed, err = t.deleteEntry(op.old)
+			if removed != nil {
+				t.notify(EventDelete, removed.Data, removed.BoundingBox, Box{})
+				undo = append(undo, func() {
+					t.insertNode(removed)
+					t.size++
+				})
+			}
+
+		default:
+			var removed, inserted *node
+			removed, inserted, err = t.updateEntry(op.old, op.data)
+			if removed != nil {
+				undo = append(undo, func() {
+					_ = t.deleteNode(inserted)
+					t.insertNode(removed)
+					t.size++
+				})
+			}
+		}
+
+		if err != nil {
+			for i := len(undo) - 1; i >= 0; i-- {
+				undo[i]()
+			}
+			t.pending = t.pending[:events]
+			return fmt.Errorf("commit: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Rollback discards the buffered operations.
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	tx.ops = nil
+	return nil
+}
@@ -0,0 +1,183 @@
+package gortree_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+)
+
+func ids(items []gortree.Spatial) []string {
+	result := make([]string, len(items))
+	for i, item := range items {
+		result[i] = item.ID()
+	}
+	slices.Sort(result)
+	return result
+}
+
+func TestRTree_Update(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	for _, location := range cityLocations {
+		rt.Insert(&location)
+	}
+
+	events, cancel := rt.Watch(*NorthAmerica)
+	defer cancel()
+
+	// Move Genova to North America
+	genova := cityLocations[0]
+	moved := genova
+	moved.Coordinates = [2]float64{-100, 40}
+
+	if err := rt.Update(&genova, &moved); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if got := len(rt.Query(*NorthAmerica)); got != 4 {
+		t.Errorf("Expected 4 entries in North America, got %d", got)
+	}
+
+	if got := len(rt.Query(genova.BoundingBox())); got != 0 {
+		t.Errorf("Expected nothing left at the old position, got %d", got)
+	}
+
+	e := receive(t, events)
+	if e.Kind != gortree.EventUpdate || e.OldBox.Rect() != genova.BoundingBox() || e.NewBox.Rect() != moved.BoundingBox() {
+		t.Errorf("Expected update event from %v to %v, got %s from %v to %v",
+			genova.BoundingBox(), moved.BoundingBox(), e.Kind, e.OldBox.Rect(), e.NewBox.Rect())
+	}
+
+	if err := rt.Update(&Location{Name: "missing"}, &moved); err == nil {
+		t.Errorf("Expected error when updating missing entry")
+	}
+}
+
+func TestTx_Commit(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	for _, location := range cityLocations[:5] {
+		rt.Insert(&location)
+	}
+
+	tx := rt.Begin()
+
+	london := cityLocations[5]
+	genova := cityLocations[0]
+	milan := cityLocations[1]
+	movedMilan := milan
+	movedMilan.Coordinates = [2]float64{-0.1, 51.5}
+
+	_ = tx.Insert(&london)
+	_ = tx.Delete(&genova)
+	_ = tx.Update(&milan, &movedMilan)
+
+	// The transaction reads its own writes
+	expected := []string{"Geneve", "London", "Milan", "Paris", "Rome"}
+	if got := ids(tx.Query(*WholeWorld)); !slices.Equal(got, expected) {
+		t.Errorf("Expected %v within the transaction, got %v", expected, got)
+	}
+
+	if got := ids(tx.Query(*gortree.NewRect(-1, 51, 0, 52))); !slices.Equal(got, []string{"London", "Milan"}) {
+		t.Errorf("Expected London and moved Milan within the transaction, got %v", got)
+	}
+
+	// Readers outside of the transaction don't see its writes
+	before := []string{"Geneve", "Genova", "Milan", "Paris", "Rome"}
+	if got := ids(rt.Query(*WholeWorld)); !slices.Equal(got, before) {
+		t.Errorf("Expected %v outside of the transaction, got %v", before, got)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if got := ids(rt.Query(*WholeWorld)); !slices.Equal(got, expected) {
+		t.Errorf("Expected %v after commit, got %v", expected, got)
+	}
+
+	if err := tx.Commit(); !errors.Is(err, gortree.ErrTxDone) {
+		t.Errorf("Expected ErrTxDone on second commit, got %v", err)
+	}
+}
+
+func TestTx_CommitFailure(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	for _, location := range cityLocations {
+		rt.Insert(&location)
+	}
+
+	events, cancel := rt.Watch(*WholeWorld)
+	defer cancel()
+
+	before := ids(rt.Query(*WholeWorld))
+
+	tx := rt.Begin()
+	for _, location := range cityLocations[:10] {
+		_ = tx.Delete(&location)
+	}
+	_ = tx.Insert(&Location{"Null Island", [2]float64{0, 0}})
+	_ = tx.Delete(&Location{Name: "missing"})
+
+	if err := tx.Commit(); err == nil {
+		t.Fatalf("Expected commit to fail")
+	}
+
+	if got := ids(rt.Query(*WholeWorld)); !slices.Equal(got, before) {
+		t.Errorf("Expected tree unchanged after failed commit, got %v", got)
+	}
+
+	if rt.Len() != len(cityLocations) {
+		t.Errorf("Expected %d entries, got %d", len(cityLocations), rt.Len())
+	}
+
+	expectNoEvent(t, events)
+}
+
+func TestTx_CommitFailure_DuplicateID(t *testing.T) {
+
+	rt := gortree.NewRTree()
+
+	original := &Location{"a", [2]float64{1, 1}}
+	rt.Insert(original)
+
+	// The undo removes the entry inserted by the transaction, not the one already holding its ID
+	tx := rt.Begin()
+	_ = tx.Insert(&Location{"a", [2]float64{2, 2}})
+	_ = tx.Delete(&Location{Name: "missing"})
+
+	if err := tx.Commit(); err == nil {
+		t.Fatalf("Expected commit to fail")
+	}
+
+	if got := rt.Entries(); len(got) != 1 || got[0] != original {
+		t.Errorf("Expected only the original entry, got %v", got)
+	}
+}
+
+func TestTx_Rollback(t *testing.T) {
+
+	rt := gortree.NewRTree()
+
+	tx := rt.Begin()
+	_ = tx.Insert(&cityLocations[0])
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	if err := tx.Insert(&cityLocations[1]); !errors.Is(err, gortree.ErrTxDone) {
+		t.Errorf("Expected ErrTxDone after rollback, got %v", err)
+	}
+
+	if err := tx.Commit(); !errors.Is(err, gortree.ErrTxDone) {
+		t.Errorf("Expected ErrTxDone on commit after rollback, got %v", err)
+	}
+
+	if got := len(rt.Entries()); got != 0 {
+		t.Errorf("Expected empty tree, got %d entries", got)
+	}
+}
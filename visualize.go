@@ -0,0 +1,155 @@
+package gortree
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+)
+
+// DefaultPalette holds the colours of the node levels, from the root down. Deeper levels reuse it from the start.
+var DefaultPalette = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b"}
+
+// SVGOptions configures the drawing of WriteSVG.
+type SVGOptions struct {
+	Width   int      // Width of the image in pixels, 800 when zero
+	Height  int      // Height of the image in pixels, following the aspect ratio of the tree when zero
+	Margin  int      // Blank space around the drawing in pixels
+	Palette []string // Colours of the node levels, DefaultPalette when empty
+	Entry   string   // Colour of the entries, grey when empty
+}
+
+// WriteSVG draws the tree as an SVG image: the bounding box of every node, coloured by level, and the box of every
+// entry, with its ID as tooltip. Only the first two dimensions are drawn, with y growing upwards.
+func (t *RTree) WriteSVG(w io.Writer, opts SVGOptions) error {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if opts.Width <= 0 {
+		opts.Width = 800
+	}
+	if len(opts.Palette) == 0 {
+		opts.Palette = DefaultPalette
+	}
+	if opts.Entry == "" {
+		opts.Entry = "#7f7f7f"
+	}
+
+	bounds := t.root.BoundingBox.Rect()
+	width, height := bounds.MaxX-bounds.MinX, bounds.MaxY-bounds.MinY
+
+	// Degenerate trees, such as a single point, are drawn in a unit square
+	if width <= 0 {
+		width = 1
+	}
+	if height <= 0 {
+		height = 1
+	}
+
+	inner := float64(opts.Width - 2*opts.Margin)
+	if opts.Height <= 0 {
+		opts.Height = int(inner*height/width) + 2*opts.Margin
+	}
+	scale := min(inner/width, float64(opts.Height-2*opts.Margin)/height)
+
+	project := func(r Rect) (x, y, w, h float64) {
+		x = float64(opts.Margin) + (r.MinX-bounds.MinX)*scale
+		y = float64(opts.Height-opts.Margin) - (r.MaxY-bounds.MinY)*scale
+		return x, y, (r.MaxX - r.MinX) * scale, (r.MaxY - r.MinY) * scale
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		opts.Width, opts.Height, opts.Width, opts.Height)
+
+	// Nodes are drawn top down, so that the deeper levels are on top
+	level := []*node{t.root}
+	for depth := 0; len(level) > 0; depth++ {
+
+		colour := opts.Palette[depth%len(opts.Palette)]
+		var next []*node
+
+		for _, n := range level {
+
+			x, y, w, h := project(n.BoundingBox.Rect())
+			fmt.Fprintf(bw, `<rect class="node level-%d" x="%g" y="%g" width="%g" height="%g" fill="none" stroke="%s"/>`+"\n",
+				depth, x, y, w, h, colour)
+
+			if !n.IsLeaf {
+				next = append(next, n.Children...)
+				continue
+			}
+
+			for _, e := range n.Children {
+				x, y, w, h := project(e.BoundingBox.Rect())
+				id := html.EscapeString(e.Data.ID())
+				if w == 0 && h == 0 {
+					fmt.Fprintf(bw, `<circle class="entry" cx="%g" cy="%g" r="2" fill="%s"><title>%s</title></circle>`+"\n",
+						x, y, opts.Entry, id)
+				} else {
+					fmt.Fprintf(bw, `<rect class="entry" x="%g" y="%g" width="%g" height="%g" fill="%s" fill-opacity="0.3"><title>%s</title></rect>`+"\n",
+						x, y, w, h, opts.Entry, id)
+				}
+			}
+		}
+
+		level = next
+	}
+
+	fmt.Fprintln(bw, "</svg>")
+
+	return bw.Flush()
+}
+
+// WriteDOT writes the node hierarchy in the Graphviz DOT language. Each node is labelled with its level, its fill
+// count out of the max entries and its bounding box. Entries are not drawn.
+func (t *RTree) WriteDOT(w io.Writer) error {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "digraph rtree {")
+	fmt.Fprintln(bw, `  node [shape=box, fontname="monospace"];`)
+
+	type item struct {
+		node  *node
+		id    int
+		depth int
+	}
+
+	queue := []item{{t.root, 0, 0}}
+	next := 1
+
+	for len(queue) > 0 {
+
+		cur := queue[0]
+		queue = queue[1:]
+
+		r := cur.node.BoundingBox.Rect()
+		style := ""
+		if cur.node.IsLeaf {
+			style = ", style=filled, fillcolor=lightgrey"
+		}
+
+		fmt.Fprintf(bw, "  n%d [label=\"level %d\\n%d/%d\\n[%g %g, %g %g]\"%s];\n",
+			cur.id, cur.depth, len(cur.node.Children), t.maxEntries, r.MinX, r.MinY, r.MaxX, r.MaxY, style)
+
+		if cur.node.IsLeaf {
+			continue
+		}
+
+		for _, child := range cur.node.Children {
+			fmt.Fprintf(bw, "  n%d -> n%d;\n", cur.id, next)
+			queue = append(queue, item{child, next, cur.depth + 1})
+			next++
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
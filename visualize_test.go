@@ -0,0 +1,121 @@
+package gortree_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+)
+
+func TestRTree_WriteSVG(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	for _, l := range randomLocations(200, 1) {
+		rt.Insert(l)
+	}
+
+	var buf bytes.Buffer
+	if err := rt.WriteSVG(&buf, gortree.SVGOptions{Width: 400, Margin: 10}); err != nil {
+		t.Fatalf("WriteSVG: %v", err)
+	}
+
+	// Count the drawn elements, which also checks the output is well formed
+	nodes, entries := 0, 0
+	levels := map[string]string{}
+
+	dec := xml.NewDecoder(&buf)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Invalid SVG: %v", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		attrs := map[string]string{}
+		for _, a := range start.Attr {
+			attrs[a.Name.Local] = a.Value
+		}
+
+		switch class := attrs["class"]; {
+		case strings.HasPrefix(class, "node "):
+			nodes++
+			if colour, ok := levels[class]; ok && colour != attrs["stroke"] {
+				t.Errorf("Expected one colour for %s, got %s and %s", class, colour, attrs["stroke"])
+			}
+			levels[class] = attrs["stroke"]
+		case class == "entry":
+			entries++
+		}
+	}
+
+	if entries != rt.Len() {
+		t.Errorf("Expected %d entries, got %d", rt.Len(), entries)
+	}
+
+	if len(levels) < 2 {
+		t.Errorf("Expected several levels, got %v", levels)
+	}
+
+	if levels["node level-0"] != gortree.DefaultPalette[0] {
+		t.Errorf("Expected root in %s, got %s", gortree.DefaultPalette[0], levels["node level-0"])
+	}
+
+	// The DOT output has the same nodes
+	buf.Reset()
+	if err := rt.WriteDOT(&buf); err != nil {
+		t.Fatalf("WriteDOT: %v", err)
+	}
+
+	dot := buf.String()
+	if got := strings.Count(dot, "label="); got != nodes {
+		t.Errorf("Expected %d nodes in DOT, got %d", nodes, got)
+	}
+	if got := strings.Count(dot, "->"); got != nodes-1 {
+		t.Errorf("Expected %d edges in DOT, got %d", nodes-1, got)
+	}
+}
+
+func TestRTree_WriteDOT(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	for _, location := range cityLocations[:3] {
+		rt.Insert(&location)
+	}
+
+	var buf bytes.Buffer
+	if err := rt.WriteDOT(&buf); err != nil {
+		t.Fatalf("WriteDOT: %v", err)
+	}
+
+	dot := buf.String()
+	if !strings.HasPrefix(dot, "digraph rtree {") || !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("Expected a digraph, got %s", dot)
+	}
+
+	// A single leaf holding the three entries
+	if !strings.Contains(dot, `n0 [label="level 0\n3/4\n`) {
+		t.Errorf("Expected root with 3/4 entries, got %s", dot)
+	}
+}
+
+func TestRTree_WriteSVG_Empty(t *testing.T) {
+
+	var buf bytes.Buffer
+	if err := gortree.NewRTree().WriteSVG(&buf, gortree.SVGOptions{}); err != nil {
+		t.Fatalf("WriteSVG: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), `width="800" height="800"`) {
+		t.Errorf("Expected default size, got %s", buf.String())
+	}
+}
@@ -0,0 +1,377 @@
+package gortree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	walFileName      = "wal.log"
+	snapshotFileName = "snapshot"
+
+	opInsert byte = 1
+	opDelete byte = 2
+)
+
+// DurableOptions configures a DurableRTree.
+type DurableOptions struct {
+	// CheckpointEvery triggers a checkpoint after the given number of logged operations. Zero disables automatic
+	// checkpoints.
+	CheckpointEvery int
+}
+
+// DurableRTree is an R-tree backed by a write-ahead log. Every Insert and Delete is appended to the log and synced to
+// disk before being applied to the tree, so acknowledged operations survive a crash. Checkpoints write the whole
+// tree to a snapshot and truncate the log.
+type DurableRTree struct {
+	mu      sync.Mutex
+	dir     string
+	codec   Codec
+	opts    DurableOptions
+	tree    *RTree
+	log     *os.File
+	offset  int64  // End of the last valid record in the log
+	seq     uint64 // Sequence number of the last logged operation
+	pending int    // Operations logged since the last checkpoint
+	ckptErr error  // Failure of the last automatic checkpoint, retried with the next operation
+}
+
+// countingReader counts the bytes consumed from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// OpenDurable opens or creates a durable tree in dir. The latest snapshot is loaded into rt, then the log is replayed
+// on top of it. A torn or corrupted record at the end of the log, left behind by a crash during a write, is discarded
+// along with anything following it. If rt is nil a tree with default parameters is used.
+func OpenDurable(dir string, codec Codec, rt *RTree, opts DurableOptions) (*DurableRTree, error) {
+
+	if codec == nil {
+		return nil, errors.New("codec is nil")
+	}
+
+	if rt == nil {
+		rt = NewRTree()
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("open durable tree: %w", err)
+	}
+
+	d := &DurableRTree{
+		dir:   dir,
+		codec: codec,
+		opts:  opts,
+		tree:  rt,
+	}
+
+	if err := d.loadSnapshot(); err != nil {
+		return nil, fmt.Errorf("open durable tree: %w", err)
+	}
+
+	log, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open durable tree: %w", err)
+	}
+	d.log = log
+
+	if err := d.replay(); err != nil {
+		_ = log.Close()
+		return nil, fmt.Errorf("open durable tree: %w", err)
+	}
+
+	return d, nil
+}
+
+// loadSnapshot loads the snapshot file, if any, into the tree.
+func (d *DurableRTree) loadSnapshot() error {
+
+	f, err := os.Open(filepath.Join(d.dir, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	seq, err := readSnapshot(f, d.codec, d.tree.Insert)
+	if err != nil {
+		return fmt.Errorf("load snapshot: %w", err)
+	}
+
+	d.seq = seq
+
+	return nil
+}
+
+// replay applies the logged operations newer than the snapshot and truncates the log after the last valid record.
+func (d *DurableRTree) replay() error {
+
+	cr := &countingReader{r: d.log}
+	br := bufio.NewReader(cr)
+	snapshotSeq := d.seq
+
+	for {
+
+		payload, err := readRecord(br)
+		if errors.Is(err, io.EOF) || errors.Is(err, errTornRecord) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("replay log: %w", err)
+		}
+
+		op, seq, data, err := d.decodeOp(payload)
+		if err != nil {
+			// A record that passes its checksum but can't be decoded is not a torn write
+			return fmt.Errorf("replay log: %w", err)
+		}
+
+		d.offset = cr.n - int64(br.Buffered())
+		d.seq = max(d.seq, seq)
+
+		// Operations already included in the snapshot
+		if seq <= snapshotSeq {
+			continue
+		}
+
+		if err := d.apply(op, data); err == nil {
+			d.pending++
+		}
+	}
+
+	if err := d.log.Truncate(d.offset); err != nil {
+		return fmt.Errorf("truncate log: %w", err)
+	}
+
+	if _, err := d.log.Seek(d.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seek log: %w", err)
+	}
+
+	return nil
+}
+
+// decodeOp splits a log record into its operation, sequence number and entry.
+func (d *DurableRTree) decodeOp(payload []byte) (byte, uint64, Spatial, error) {
+
+	if len(payload) < 9 {
+		return 0, 0, nil, errors.New("log record too short")
+	}
+
+	op := payload[0]
+	if op != opInsert && op != opDelete {
+		return 0, 0, nil, fmt.Errorf("unknown log operation %d", op)
+	}
+
+	seq := binary.LittleEndian.Uint64(payload[1:9])
+
+	data, err := d.codec.Decode(payload[9:])
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("decode record %d: %w", seq, err)
+	}
+
+	return op, seq, data, nil
+}
+
+// apply performs the operation on the tree.
+func (d *DurableRTree) apply(op byte, data Spatial) error {
+	if op == opDelete {
+		return d.tree.Delete(data)
+	}
+	d.tree.Insert(data)
+	return nil
+}
+
+// append writes the operation to the log and syncs it. On failure the log is truncated back to its previous end, so
+// that a partial record doesn't hide the following ones.
+func (d *DurableRTree) append(op byte, data Spatial) error {
+
+	encoded, err := d.codec.Encode(data)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", data.ID(), err)
+	}
+
+	seq := d.seq + 1
+
+	payload := make([]byte, 0, 9+len(encoded))
+	payload = append(payload, op)
+	payload = binary.LittleEndian.AppendUint64(payload, seq)
+	payload = append(payload, encoded...)
+
+	if err := writeRecord(d.log, payload); err != nil {
+		return d.rewind(fmt.Errorf("append log: %w", err))
+	}
+
+	if err := d.log.Sync(); err != nil {
+		return d.rewind(fmt.Errorf("sync log: %w", err))
+	}
+
+	d.offset += int64(recordHeaderSize + len(payload))
+	d.seq = seq
+	d.pending++
+
+	return nil
+}
+
+// rewind truncates the log to the end of the last valid record and returns cause.
+func (d *DurableRTree) rewind(cause error) error {
+	if err := d.log.Truncate(d.offset); err != nil {
+		return errors.Join(cause, err)
+	}
+	if _, err := d.log.Seek(d.offset, io.SeekStart); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
+
+// Insert logs the entry and adds it to the tree.
+func (d *DurableRTree) Insert(data Spatial) error {
+
+	if data == nil {
+		return errors.New("data is nil")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.append(opInsert, data); err != nil {
+		return err
+	}
+
+	_ = d.apply(opInsert, data)
+	d.maybeCheckpoint()
+
+	return nil
+}
+
+// Delete logs the deletion and removes the entry from the tree.
+func (d *DurableRTree) Delete(data Spatial) error {
+
+	if data == nil {
+		return errors.New("data is nil")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Nothing is logged for entries missing from the tree
+	if !d.tree.contains(data) {
+		return errNotFound
+	}
+
+	if err := d.append(opDelete, data); err != nil {
+		return err
+	}
+
+	if err := d.apply(opDelete, data); err != nil {
+		return err
+	}
+	d.maybeCheckpoint()
+
+	return nil
+}
+
+// maybeCheckpoint checkpoints when the configured number of operations has been logged. The operation is already
+// logged and applied, so a failure isn't returned to its caller, who could retry it: it is kept until a checkpoint
+// succeeds, and reported by Checkpoint and Close. Requires d.mu held.
+func (d *DurableRTree) maybeCheckpoint() {
+	if d.opts.CheckpointEvery <= 0 || d.pending < d.opts.CheckpointEvery {
+		return
+	}
+	d.ckptErr = d.checkpoint()
+}
+
+// Checkpoint writes the tree to a new snapshot and truncates the log. On success it clears the failure of a previous
+// automatic checkpoint.
+func (d *DurableRTree) Checkpoint() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ckptErr = d.checkpoint()
+	return d.ckptErr
+}
+
+// checkpoint requires d.mu held. The snapshot is written to a temporary file and renamed over the previous one, so a
+// crash leaves either the old or the new snapshot in place. Log records already covered by the snapshot are skipped
+// on replay, which makes a crash between the rename and the log truncation harmless.
+func (d *DurableRTree) checkpoint() error {
+
+	path := filepath.Join(d.dir, snapshotFileName)
+	tmp := path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+
+	if err := writeSnapshot(f, d.codec, d.tree.Entries(), d.seq); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+
+	if err := syncDir(d.dir); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+
+	d.offset = 0
+	d.pending = 0
+
+	if err := d.rewind(nil); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+
+	if err := d.log.Sync(); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// syncDir flushes the directory entry changes to disk.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// Tree returns the underlying tree. It must only be used for reads, writes bypassing the log would be lost.
+func (d *DurableRTree) Tree() *RTree {
+	return d.tree
+}
+
+// Close closes the log file. It also returns the failure of the last automatic checkpoint, if none succeeded since:
+// the logged operations are safe, but the log hasn't been truncated.
+func (d *DurableRTree) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return errors.Join(d.ckptErr, d.log.Close())
+}
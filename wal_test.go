@@ -0,0 +1,283 @@
+package gortree_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+)
+
+type locationCodec struct{}
+
+func (locationCodec) Encode(data gortree.Spatial) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+func (locationCodec) Decode(b []byte) (gortree.Spatial, error) {
+	var l Location
+	if err := json.Unmarshal(b, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func openDurable(t *testing.T, dir string, opts gortree.DurableOptions) *gortree.DurableRTree {
+	t.Helper()
+	d, err := gortree.OpenDurable(dir, locationCodec{}, nil, opts)
+	if err != nil {
+		t.Fatalf("OpenDurable: %v", err)
+	}
+	return d
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+
+	rt := gortree.NewRTree()
+	for _, location := range cityLocations {
+		rt.Insert(&location)
+	}
+
+	var buf bytes.Buffer
+	if err := rt.WriteSnapshot(&buf, locationCodec{}); err != nil {
+		t.Fatalf("WriteSnapshot: %v", err)
+	}
+
+	restored := gortree.NewRTree()
+	if err := restored.ReadSnapshot(&buf, locationCodec{}); err != nil {
+		t.Fatalf("ReadSnapshot: %v", err)
+	}
+
+	if got := len(restored.Query(*WholeWorld)); got != len(cityLocations) {
+		t.Errorf("Expected %d entries, got %d", len(cityLocations), got)
+	}
+}
+
+func TestDurableRTree_Replay(t *testing.T) {
+
+	dir := t.TempDir()
+	d := openDurable(t, dir, gortree.DurableOptions{})
+
+	for _, location := range cityLocations {
+		if err := d.Insert(&location); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	genova := cityLocations[0]
+	if err := d.Delete(&genova); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	_ = d.Close()
+
+	d = openDurable(t, dir, gortree.DurableOptions{})
+	defer d.Close()
+
+	if got := len(d.Tree().Entries()); got != len(cityLocations)-1 {
+		t.Errorf("Expected %d entries after replay, got %d", len(cityLocations)-1, got)
+	}
+
+	if res := d.Tree().Query(genova.BoundingBox()); len(res) != 0 {
+		t.Errorf("Expected %s to stay deleted, got %d entries", genova.ID(), len(res))
+	}
+}
+
+func TestDurableRTree_DeleteMissing(t *testing.T) {
+
+	dir := t.TempDir()
+	d := openDurable(t, dir, gortree.DurableOptions{CheckpointEvery: 2})
+	defer d.Close()
+
+	genova := cityLocations[0]
+	if err := d.Insert(&genova); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	before, err := os.Stat(filepath.Join(dir, "wal.log"))
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+
+	missing := cityLocations[1]
+	for i := 0; i < 3; i++ {
+		if err := d.Delete(&missing); err == nil {
+			t.Fatalf("Expected an error deleting %s, missing from the tree", missing.ID())
+		}
+	}
+
+	// Nothing is logged, and the failed deletes don't trigger a checkpoint
+	after, err := os.Stat(filepath.Join(dir, "wal.log"))
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if after.Size() != before.Size() {
+		t.Errorf("Expected the log to stay at %d bytes, got %d", before.Size(), after.Size())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "snapshot")); err == nil {
+		t.Errorf("Expected no checkpoint after failed deletes")
+	}
+}
+
+func TestDurableRTree_CheckpointFailure(t *testing.T) {
+
+	dir := t.TempDir()
+	d := openDurable(t, dir, gortree.DurableOptions{CheckpointEvery: 2})
+
+	// The snapshot can't be written while its temporary file is a directory
+	blocker := filepath.Join(dir, "snapshot.tmp")
+	if err := os.Mkdir(blocker, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The operations succeed, so that callers don't retry them
+	for _, location := range cityLocations[:3] {
+		if err := d.Insert(&location); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	if got := d.Tree().Len(); got != 3 {
+		t.Errorf("Expected 3 entries, got %d", got)
+	}
+
+	// The failure is retried with the next operation, and cleared once a checkpoint succeeds
+	if err := os.Remove(blocker); err != nil {
+		t.Fatal(err)
+	}
+	genova := cityLocations[0]
+	if err := d.Delete(&genova); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "snapshot")); err != nil {
+		t.Errorf("Expected a snapshot after the retry, got %v", err)
+	}
+	if err := d.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+
+	// A failure left behind is reported by Close
+	d = openDurable(t, dir, gortree.DurableOptions{CheckpointEvery: 1})
+	if err := os.Mkdir(blocker, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Insert(&genova); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := d.Close(); err == nil {
+		t.Errorf("Expected Close to report the failed checkpoint")
+	}
+
+	d = openDurable(t, dir, gortree.DurableOptions{})
+	defer d.Close()
+
+	if got := d.Tree().Len(); got != 3 {
+		t.Errorf("Expected 3 entries after replay, got %d", got)
+	}
+}
+
+func TestDurableRTree_TornWrite(t *testing.T) {
+
+	testCases := []struct {
+		Name   string
+		Damage func(path string) error
+	}{
+		{"Truncated record", func(path string) error {
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			return os.Truncate(path, info.Size()-3)
+		}},
+		{"Garbage tail", func(path string) error {
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = f.Write([]byte{42, 0, 0, 0, 1, 2, 3, 4, 'x'})
+			return err
+		}},
+		{"Flipped byte", func(path string) error {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			b[len(b)-1] ^= 0xff
+			return os.WriteFile(path, b, 0o644)
+		}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+
+			dir := t.TempDir()
+			d := openDurable(t, dir, gortree.DurableOptions{})
+
+			for _, location := range cityLocations {
+				_ = d.Insert(&location)
+			}
+			_ = d.Close()
+
+			if err := testCase.Damage(filepath.Join(dir, "wal.log")); err != nil {
+				t.Fatalf("damage log: %v", err)
+			}
+
+			d = openDurable(t, dir, gortree.DurableOptions{})
+
+			// Only the damaged record may be lost
+			got := len(d.Tree().Entries())
+			if got < len(cityLocations)-1 || got > len(cityLocations) {
+				t.Errorf("Expected at least %d entries after recovery, got %d", len(cityLocations)-1, got)
+			}
+
+			// The log must accept new writes after recovery
+			extra := Location{"Extra", [2]float64{1, 1}}
+			if err := d.Insert(&extra); err != nil {
+				t.Fatalf("Insert after recovery: %v", err)
+			}
+			_ = d.Close()
+
+			d = openDurable(t, dir, gortree.DurableOptions{})
+			defer d.Close()
+
+			if res := d.Tree().Query(extra.BoundingBox()); len(res) != 1 {
+				t.Errorf("Expected insert after recovery to be replayed, got %d entries", len(res))
+			}
+		})
+	}
+}
+
+func TestDurableRTree_Checkpoint(t *testing.T) {
+
+	dir := t.TempDir()
+	d := openDurable(t, dir, gortree.DurableOptions{CheckpointEvery: 5})
+
+	for _, location := range cityLocations {
+		_ = d.Insert(&location)
+	}
+
+	if err := d.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "wal.log"))
+	if err != nil {
+		t.Fatalf("stat log: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("Expected empty log after checkpoint, got %d bytes", info.Size())
+	}
+
+	genova := cityLocations[0]
+	_ = d.Delete(&genova)
+	_ = d.Close()
+
+	d = openDurable(t, dir, gortree.DurableOptions{})
+	defer d.Close()
+
+	if got := len(d.Tree().Entries()); got != len(cityLocations)-1 {
+		t.Errorf("Expected %d entries, got %d", len(cityLocations)-1, got)
+	}
+}
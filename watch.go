@@ -0,0 +1,223 @@
+package gortree
+
+import (
+	"slices"
+	"sync"
+)
+
+// EventKind is the kind of change published to watchers.
+type EventKind int
+
+const (
+	// EventInsert is published when an entry is inserted.
+	EventInsert EventKind = iota
+	// EventDelete is published when an entry is deleted, including expired entries removed by Reap.
+	EventDelete
+	// EventUpdate is published when an entry is moved.
+	EventUpdate
+)
+
+// String returns the kind name.
+func (k EventKind) String() string {
+	switch k {
+	case EventInsert:
+		return "insert"
+	case EventDelete:
+		return "delete"
+	case EventUpdate:
+		return "update"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a change of the tree. OldBox is zero for inserts, NewBox is zero for deletes.
+type Event struct {
+	Kind   EventKind
+	Entry  Spatial
+	OldBox Box
+	NewBox Box
+}
+
+// OverflowPolicy decides what happens when a watcher buffer is full.
+type OverflowPolicy int
+
+const (
+	// DropNewest discards the event being published.
+	DropNewest OverflowPolicy = iota
+	// DropOldest discards the oldest buffered event to make room for the new one.
+	DropOldest
+	// Block waits for the watcher to receive the event. Writers are delayed by slow consumers, readers are not: events
+	// are delivered once the write lock is released.
+	Block
+)
+
+// DefaultWatchBuffer is the buffer size of watchers created by Watch.
+const DefaultWatchBuffer = 64
+
+// WatchOptions configures a watcher.
+type WatchOptions struct {
+	// Buffer is the number of events buffered for the consumer. Zero means unbuffered.
+	Buffer int
+	// Policy is applied when the buffer is full.
+	Policy OverflowPolicy
+}
+
+// watcher is a subscription to the changes within a region.
+type watcher struct {
+	region Box
+	opts   WatchOptions
+	events chan Event
+	done   chan struct{}
+
+	mu     sync.Mutex // Held while delivering, so that events isn't closed during a send
+	closed bool
+}
+
+// Watch subscribes to the changes within the region, using a buffer of DefaultWatchBuffer events and dropping new
+// events when it's full. The returned function cancels the subscription and closes the channel.
+func (t *RTree) Watch(r Rect) (<-chan Event, func()) {
+	return t.WatchWithOptions(r, WatchOptions{Buffer: DefaultWatchBuffer, Policy: DropNewest})
+}
+
+// WatchWithOptions is Watch with a custom buffer size and overflow policy.
+func (t *RTree) WatchWithOptions(r Rect, opts WatchOptions) (<-chan Event, func()) {
+
+	w := &watcher{
+		region: r.Box(),
+		opts:   opts,
+		events: make(chan Event, max(opts.Buffer, 0)),
+		done:   make(chan struct{}),
+	}
+
+	t.watchMu.Lock()
+	t.watchers = append(t.watchers, w)
+	t.watching.Add(1)
+	t.watchMu.Unlock()
+
+	var once sync.Once
+
+	cancel := func() {
+		once.Do(func() {
+			// Unblock a pending delivery before waiting for the lock held while delivering
+			close(w.done)
+
+			t.watchMu.Lock()
+			for i, other := range t.watchers {
+				if other == w {
+					t.watchers = append(t.watchers[:i], t.watchers[i+1:]...)
+					break
+				}
+			}
+			t.watching.Add(-1)
+			t.watchMu.Unlock()
+
+			w.mu.Lock()
+			defer w.mu.Unlock()
+
+			w.closed = true
+			close(w.events)
+		})
+	}
+
+	return w.events, cancel
+}
+
+// notify queues the event for the watchers, it is delivered by unlock. Requires t.mu held for writing.
+func (t *RTree) notify(kind EventKind, entry Spatial, oldBox, newBox Box) {
+	if t.watching.Load() == 0 {
+		return
+	}
+	t.pending = append(t.pending, Event{Kind: kind, Entry: entry, OldBox: oldBox, NewBox: newBox})
+}
+
+// unlock releases the write lock and delivers the queued events, with no lock held, so that slow watchers delay
+// writers but not readers. Each write with events takes its turn after the previous one has delivered its events, so
+// events are delivered in the order the changes were applied.
+func (t *RTree) unlock() {
+
+	t.version++
+
+	events := t.pending
+	t.pending = nil
+
+	if len(events) == 0 {
+		t.mu.Unlock()
+		return
+	}
+
+	t.watchMu.Lock()
+	watchers := slices.Clone(t.watchers)
+	t.watchMu.Unlock()
+
+	previous := t.delivered
+	delivered := make(chan struct{})
+	t.delivered = delivered
+
+	t.mu.Unlock()
+
+	defer close(delivered)
+	if previous != nil {
+		<-previous
+	}
+
+	for _, e := range events {
+		for _, w := range watchers {
+			if w.interested(e) {
+				w.deliver(e)
+			}
+		}
+	}
+}
+
+// interested tells whether the change happened within the watched region.
+func (w *watcher) interested(e Event) bool {
+	return (e.Kind != EventInsert && w.region.Intersects(e.OldBox)) ||
+		(e.Kind != EventDelete && w.region.Intersects(e.NewBox))
+}
+
+// deliver sends the event according to the overflow policy, unless the watcher is cancelled.
+func (w *watcher) deliver(e Event) {
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return
+	}
+
+	switch w.opts.Policy {
+
+	case Block:
+		select {
+		case w.events <- e:
+		case <-w.done:
+		}
+
+	case DropOldest:
+		for cap(w.events) > 0 {
+			select {
+			case w.events <- e:
+				return
+			default:
+			}
+			// Make room, unless the consumer just did
+			select {
+			case <-w.events:
+			default:
+			}
+		}
+
+		// Nothing to drop without a buffer
+		select {
+		case w.events <- e:
+		default:
+		}
+
+	default:
+		select {
+		case w.events <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package gortree_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lambertmata/gortree"
+)
+
+func receive(t *testing.T, events <-chan gortree.Event) gortree.Event {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatalf("Expected an event")
+		return gortree.Event{}
+	}
+}
+
+func expectNoEvent(t *testing.T, events <-chan gortree.Event) {
+	t.Helper()
+	select {
+	case e := <-events:
+		t.Errorf("Expected no event, got %s of %s", e.Kind, e.Entry.ID())
+	default:
+	}
+}
+
+func TestRTree_Watch(t *testing.T) {
+
+	rt := gortree.NewRTree()
+
+	events, cancel := rt.Watch(*NorthAmerica)
+	defer cancel()
+
+	for _, location := range cityLocations {
+		rt.Insert(&location)
+	}
+
+	// Only New York, Los Angeles and Mexico City are in North America
+	for _, expected := range []string{"New York", "Los Angeles", "Mexico City"} {
+		e := receive(t, events)
+		if e.Kind != gortree.EventInsert || e.Entry.ID() != expected {
+			t.Errorf("Expected insert of %s, got %s of %s", expected, e.Kind, e.Entry.ID())
+		}
+	}
+	expectNoEvent(t, events)
+
+	newYork := cityLocations[6]
+	if err := rt.Delete(&newYork); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	e := receive(t, events)
+	if e.Kind != gortree.EventDelete || e.Entry.ID() != newYork.ID() || e.OldBox.Rect() != newYork.BoundingBox() {
+		t.Errorf("Expected delete of %s, got %s of %s", newYork.ID(), e.Kind, e.Entry.ID())
+	}
+
+	// Changes elsewhere are not published
+	genova := cityLocations[0]
+	_ = rt.Delete(&genova)
+	expectNoEvent(t, events)
+
+	cancel()
+	if _, ok := <-events; ok {
+		t.Errorf("Expected channel to be closed after cancel")
+	}
+
+	// Cancelling twice is harmless
+	cancel()
+}
+
+func TestRTree_WatchOverflow(t *testing.T) {
+
+	testCases := []struct {
+		Name     string
+		Policy   gortree.OverflowPolicy
+		Expected []string
+	}{
+		{"Drop newest", gortree.DropNewest, []string{"Genova", "Milan"}},
+		{"Drop oldest", gortree.DropOldest, []string{"Sydney", "Dubai"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+
+			rt := gortree.NewRTree()
+
+			events, cancel := rt.WatchWithOptions(*WholeWorld, gortree.WatchOptions{Buffer: 2, Policy: testCase.Policy})
+			defer cancel()
+
+			for _, location := range cityLocations[:11] {
+				rt.Insert(&location)
+			}
+
+			for _, expected := range testCase.Expected {
+				if e := receive(t, events); e.Entry.ID() != expected {
+					t.Errorf("Expected %s, got %s", expected, e.Entry.ID())
+				}
+			}
+			expectNoEvent(t, events)
+		})
+	}
+}
+
+func TestRTree_WatchBlock(t *testing.T) {
+
+	rt := gortree.NewRTree()
+
+	events, cancel := rt.WatchWithOptions(*WholeWorld, gortree.WatchOptions{Policy: gortree.Block})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, location := range cityLocations {
+			rt.Insert(&location)
+		}
+	}()
+
+	// Every event is received
+	for _, location := range cityLocations {
+		if e := receive(t, events); e.Entry.ID() != location.ID() {
+			t.Errorf("Expected %s, got %s", location.ID(), e.Entry.ID())
+		}
+	}
+
+	<-done
+
+	// Readers are not blocked by a watcher which is not receiving
+	go func() {
+		rt.Insert(&Location{"Blocked", [2]float64{0, 0}})
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for len(rt.Query(*WholeWorld)) != len(cityLocations)+1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected blocked insert to be visible to readers")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Nor by a second writer waiting for the blocked one to deliver its event
+	go func() {
+		rt.Insert(&Location{"Waiting", [2]float64{0, 0}})
+	}()
+
+	deadline = time.Now().Add(time.Second)
+	for len(rt.Query(*WholeWorld)) != len(cityLocations)+2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected both blocked inserts to be visible to readers")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	queried := make(chan struct{})
+	go func() {
+		defer close(queried)
+		rt.Query(*WholeWorld)
+	}()
+
+	select {
+	case <-queried:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatalf("Expected readers not to wait for the blocked writers")
+	}
+
+	// The blocked events are delivered in order
+	for _, expected := range []string{"Blocked", "Waiting"} {
+		if e := receive(t, events); e.Entry.ID() != expected {
+			t.Errorf("Expected %s, got %s", expected, e.Entry.ID())
+		}
+	}
+
+	// Cancelling releases the blocked writers
+	released := make(chan struct{})
+	go func() {
+		defer close(released)
+		rt.Insert(&Location{"Blocked again", [2]float64{0, 0}})
+		rt.Insert(&Location{"Released", [2]float64{0, 0}})
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	<-released
+}
@@ -0,0 +1,392 @@
+// Package wkb decodes and encodes geometries in the OGC Well-Known Binary format.
+package wkb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/lambertmata/gortree/geom"
+)
+
+// Geometry type codes
+const (
+	typePoint              = 1
+	typeLineString         = 2
+	typePolygon            = 3
+	typeMultiPoint         = 4
+	typeMultiLineString    = 5
+	typeMultiPolygon       = 6
+	typeGeometryCollection = 7
+)
+
+// EWKB flags, set in the high bits of the type
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// minElementSize is the size of the smallest element of a list, the count of an empty ring. Counts implying more bytes
+// than left in the input fail instead of allocating.
+const minElementSize = 4
+
+// maxDepth is the maximum nesting of geometry collections, so that crafted input can't exhaust the stack.
+const maxDepth = 32
+
+// Unmarshal decodes a WKB geometry, in either byte order. Z, M and ZM geometries, both ISO and EWKB, are accepted and
+// projected on x and y, and an EWKB SRID is ignored. Empty points, encoded with NaN coordinates, are not supported, and
+// geometry collections nest at most 32 deep.
+func Unmarshal(b []byte) (geom.Geometry, error) {
+
+	r := bytes.NewReader(b)
+
+	g, err := read(r, 0)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("unmarshal wkb: %w", err)
+	}
+
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("unmarshal wkb: %d trailing bytes", r.Len())
+	}
+
+	return g, nil
+}
+
+// UnmarshalShape decodes a WKB geometry into a shape with the given ID, ready to be inserted into a gortree.RTree.
+// Empty geometries, which have no bounds, are rejected.
+func UnmarshalShape(id string, b []byte) (*geom.Shape, error) {
+	g, err := Unmarshal(b)
+	if err != nil {
+		return nil, err
+	}
+	if geom.IsEmpty(g) {
+		return nil, errors.New("unmarshal wkb: empty geometry")
+	}
+	return &geom.Shape{Name: id, Geometry: g}, nil
+}
+
+// decoder reads values in the byte order of the current geometry.
+type decoder struct {
+	r     *bytes.Reader
+	order binary.ByteOrder
+	dims  int
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	var v uint32
+	err := binary.Read(d.r, d.order, &v)
+	return v, err
+}
+
+func (d *decoder) count() (int, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return 0, err
+	}
+	if int64(n)*minElementSize > int64(d.r.Len()) {
+		return 0, fmt.Errorf("count %d exceeds the input", n)
+	}
+	return int(n), nil
+}
+
+func (d *decoder) point() (geom.Point, error) {
+
+	var p geom.Point
+	var extra float64
+
+	for i := 0; i < d.dims; i++ {
+		dst := &extra
+		if i < 2 {
+			dst = &p[i]
+		}
+		if err := binary.Read(d.r, d.order, dst); err != nil {
+			return p, err
+		}
+	}
+
+	if math.IsNaN(p[0]) || math.IsNaN(p[1]) {
+		return p, errors.New("empty points are not supported")
+	}
+
+	return p, nil
+}
+
+func (d *decoder) lineString() (geom.LineString, error) {
+	n, err := d.count()
+	if err != nil {
+		return nil, err
+	}
+	l := make(geom.LineString, n)
+	for i := range l {
+		if l[i], err = d.point(); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (d *decoder) polygon() (geom.Polygon, error) {
+	n, err := d.count()
+	if err != nil {
+		return nil, err
+	}
+	p := make(geom.Polygon, n)
+	for i := range p {
+		if p[i], err = d.lineString(); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// header reads the byte order and the type of a geometry, returning a decoder for its body and its base type.
+func header(r *bytes.Reader) (*decoder, uint32, error) {
+
+	order, err := r.ReadByte()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	d := &decoder{r: r, dims: 2}
+
+	switch order {
+	case 0:
+		d.order = binary.BigEndian
+	case 1:
+		d.order = binary.LittleEndian
+	default:
+		return nil, 0, fmt.Errorf("invalid byte order %d", order)
+	}
+
+	typ, err := d.uint32()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typ&ewkbZ != 0 {
+		d.dims++
+	}
+	if typ&ewkbM != 0 {
+		d.dims++
+	}
+	if typ&ewkbSRID != 0 {
+		if _, err := d.uint32(); err != nil {
+			return nil, 0, err
+		}
+	}
+	typ &^= ewkbZ | ewkbM | ewkbSRID
+
+	// ISO codes: 1000 for Z, 2000 for M, 3000 for ZM
+	switch typ / 1000 {
+	case 0:
+	case 1, 2:
+		d.dims++
+	case 3:
+		d.dims += 2
+	default:
+		return nil, 0, fmt.Errorf("unsupported geometry type %d", typ)
+	}
+	typ %= 1000
+
+	if d.dims > 4 {
+		return nil, 0, fmt.Errorf("invalid dimensions for geometry type %d", typ)
+	}
+
+	return d, typ, nil
+}
+
+// read decodes a geometry starting with its byte order, nested in depth geometry collections.
+func read(r *bytes.Reader, depth int) (geom.Geometry, error) {
+
+	d, typ, err := header(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Multi geometries hold simple geometries of their type, each with its own header, and never recurse
+	members := func(expected uint32, name string, fn func(d *decoder) error) error {
+		n, err := d.count()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			md, typ, err := header(r)
+			if err != nil {
+				return err
+			}
+			if typ != expected {
+				return fmt.Errorf("expected %s, got geometry type %d", name, typ)
+			}
+			if err := fn(md); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch typ {
+
+	case typePoint:
+		return d.point()
+
+	case typeLineString:
+		return d.lineString()
+
+	case typePolygon:
+		return d.polygon()
+
+	case typeMultiPoint:
+		m := geom.MultiPoint{}
+		err := members(typePoint, "point in multipoint", func(d *decoder) error {
+			p, err := d.point()
+			m = append(m, p)
+			return err
+		})
+		return m, err
+
+	case typeMultiLineString:
+		m := geom.MultiLineString{}
+		err := members(typeLineString, "line string in multilinestring", func(d *decoder) error {
+			l, err := d.lineString()
+			m = append(m, l)
+			return err
+		})
+		return m, err
+
+	case typeMultiPolygon:
+		m := geom.MultiPolygon{}
+		err := members(typePolygon, "polygon in multipolygon", func(d *decoder) error {
+			p, err := d.polygon()
+			m = append(m, p)
+			return err
+		})
+		return m, err
+
+	case typeGeometryCollection:
+		if depth == maxDepth {
+			return nil, fmt.Errorf("geometry collections nested deeper than %d", maxDepth)
+		}
+		// Collections hold complete geometries
+		n, err := d.count()
+		if err != nil {
+			return nil, err
+		}
+		c := geom.GeometryCollection{}
+		for i := 0; i < n; i++ {
+			g, err := read(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, g)
+		}
+		return c, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %d", typ)
+	}
+}
+
+// Marshal encodes the geometry as two dimensional WKB in the given byte order.
+func Marshal(g geom.Geometry, order binary.ByteOrder) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := write(&buf, g, order); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// write appends the geometry with its header to buf.
+func write(buf *bytes.Buffer, g geom.Geometry, order binary.ByteOrder) error {
+
+	// Writes to a bytes.Buffer never fail
+	header := func(typ uint32) {
+		if order == binary.BigEndian {
+			buf.WriteByte(0)
+		} else {
+			buf.WriteByte(1)
+		}
+		_ = binary.Write(buf, order, typ)
+	}
+
+	count := func(n int) {
+		_ = binary.Write(buf, order, uint32(n))
+	}
+
+	point := func(p geom.Point) {
+		_ = binary.Write(buf, order, p)
+	}
+
+	lineString := func(l geom.LineString) {
+		count(len(l))
+		for _, p := range l {
+			point(p)
+		}
+	}
+
+	polygon := func(p geom.Polygon) {
+		count(len(p))
+		for _, ring := range p {
+			lineString(ring)
+		}
+	}
+
+	switch g := g.(type) {
+
+	case geom.Point:
+		header(typePoint)
+		point(g)
+
+	case geom.LineString:
+		header(typeLineString)
+		lineString(g)
+
+	case geom.Polygon:
+		header(typePolygon)
+		polygon(g)
+
+	case geom.MultiPoint:
+		header(typeMultiPoint)
+		count(len(g))
+		for _, p := range g {
+			header(typePoint)
+			point(p)
+		}
+
+	case geom.MultiLineString:
+		header(typeMultiLineString)
+		count(len(g))
+		for _, l := range g {
+			header(typeLineString)
+			lineString(l)
+		}
+
+	case geom.MultiPolygon:
+		header(typeMultiPolygon)
+		count(len(g))
+		for _, p := range g {
+			header(typePolygon)
+			polygon(p)
+		}
+
+	case geom.GeometryCollection:
+		header(typeGeometryCollection)
+		count(len(g))
+		for _, c := range g {
+			if err := write(buf, c, order); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("unsupported geometry %T", g)
+	}
+
+	return nil
+}
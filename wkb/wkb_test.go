@@ -0,0 +1,165 @@
+package wkb_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/geom"
+	"github.com/lambertmata/gortree/wkb"
+)
+
+func TestUnmarshal(t *testing.T) {
+
+	testCases := []struct {
+		Name     string
+		Hex      string
+		Expected geom.Geometry
+	}{
+		{"Point little endian", "0101000000000000000000f03f0000000000000040", geom.Point{1, 2}},
+		{"Point big endian", "00000000013ff00000000000004000000000000000", geom.Point{1, 2}},
+		{"Point Z, ISO", "01e9030000000000000000f03f00000000000000400000000000000840", geom.Point{1, 2}},
+		{"Point Z, EWKB with SRID", "01010000a0e6100000000000000000f03f00000000000000400000000000000840", geom.Point{1, 2}},
+		{"LineString", "010200000002000000000000000000000000000000000000000000000000002440000000000000f03f",
+			geom.LineString{{0, 0}, {10, 1}}},
+		{"MultiPoint", "0104000000020000000101000000000000000000f03f0000000000000040010100000000000000000008400000000000001040",
+			geom.MultiPoint{{1, 2}, {3, 4}}},
+	}
+
+	for _, testCase := range testCases {
+		b, err := hex.DecodeString(testCase.Hex)
+		if err != nil {
+			t.Fatalf("Invalid test input %s: %v", testCase.Name, err)
+		}
+		got, err := wkb.Unmarshal(b)
+		if err != nil {
+			t.Errorf("Unmarshal %s: %v", testCase.Name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, testCase.Expected) {
+			t.Errorf("Expected %v for %s, got %v", testCase.Expected, testCase.Name, got)
+		}
+	}
+}
+
+func TestUnmarshal_Invalid(t *testing.T) {
+
+	testCases := []struct {
+		Name string
+		Hex  string
+	}{
+		{"Empty", ""},
+		{"Bad byte order", "0201000000000000000000f03f0000000000000040"},
+		{"Unknown type", "0109000000"},
+		{"Truncated", "0101000000000000000000f03f"},
+		{"Trailing bytes", "0101000000000000000000f03f000000000000004000"},
+		{"Huge count", "0102000000ffffffff"},
+		{"Empty point", "0101000000000000000000f87f000000000000f87f"},
+		{"Wrong member", "010400000001000000010200000000000000"},
+	}
+
+	for _, testCase := range testCases {
+		b, err := hex.DecodeString(testCase.Hex)
+		if err != nil {
+			t.Fatalf("Invalid test input %s: %v", testCase.Name, err)
+		}
+		if _, err := wkb.Unmarshal(b); err == nil {
+			t.Errorf("Expected error for %s", testCase.Name)
+		}
+	}
+}
+
+func TestUnmarshal_Depth(t *testing.T) {
+
+	// depth geometry collections nested in each other around a point
+	nested := func(depth int) []byte {
+		var b []byte
+		for i := 0; i < depth; i++ {
+			b = append(b, 1, 7, 0, 0, 0, 1, 0, 0, 0)
+		}
+		point, _ := hex.DecodeString("0101000000000000000000f03f0000000000000040")
+		return append(b, point...)
+	}
+
+	if _, err := wkb.Unmarshal(nested(32)); err != nil {
+		t.Errorf("Expected 32 nested collections to decode, got %v", err)
+	}
+
+	for _, depth := range []int{33, 1_000_000} {
+		if _, err := wkb.Unmarshal(nested(depth)); err == nil {
+			t.Errorf("Expected error for %d nested collections", depth)
+		}
+	}
+	// Multi geometries only hold simple geometries, and never nest
+	var multi []byte
+	for i := 0; i < 1_000_000; i++ {
+		multi = append(multi, 1, 4, 0, 0, 0, 1, 0, 0, 0)
+	}
+	point, _ := hex.DecodeString("0101000000000000000000f03f0000000000000040")
+	if _, err := wkb.Unmarshal(append(multi, point...)); err == nil {
+		t.Error("Expected error for nested multipoints")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+
+	geometries := []geom.Geometry{
+		geom.Point{1.5, -2},
+		geom.LineString{{0, 0}, {10, 5}},
+		geom.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}, {{1, 1}, {2, 1}, {2, 2}, {1, 1}}},
+		geom.MultiPoint{{1, 2}, {3, 4}},
+		geom.MultiLineString{{{0, 0}, {1, 1}}, {}},
+		geom.MultiPolygon{{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}},
+		geom.GeometryCollection{geom.Point{1, 2}, geom.GeometryCollection{}},
+	}
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, g := range geometries {
+			b, err := wkb.Marshal(g, order)
+			if err != nil {
+				t.Fatalf("Marshal %v: %v", g, err)
+			}
+			got, err := wkb.Unmarshal(b)
+			if err != nil {
+				t.Fatalf("Unmarshal %v: %v", g, err)
+			}
+			if !reflect.DeepEqual(got, g) {
+				t.Errorf("Expected %v after round trip in %v, got %v", g, order, got)
+			}
+		}
+	}
+
+	// Matches the reference encoding
+	b, _ := wkb.Marshal(geom.Point{1, 2}, binary.LittleEndian)
+	if got := hex.EncodeToString(b); got != "0101000000000000000000f03f0000000000000040" {
+		t.Errorf("Unexpected encoding %s", got)
+	}
+}
+
+func TestUnmarshalShape(t *testing.T) {
+
+	b, _ := wkb.Marshal(geom.LineString{{0, 0}, {10, 5}}, binary.LittleEndian)
+
+	shape, err := wkb.UnmarshalShape("road", b)
+	if err != nil {
+		t.Fatalf("UnmarshalShape: %v", err)
+	}
+
+	rt := gortree.NewRTree()
+	rt.Insert(shape)
+
+	res := rt.Query(*gortree.NewRect(9, 4, 11, 6))
+	if len(res) != 1 || res[0].ID() != "road" {
+		t.Errorf("Expected road, got %v", res)
+	}
+
+	// Empty geometries have no bounds to index
+	for _, g := range []geom.Geometry{geom.LineString{}, geom.MultiPoint{}, geom.GeometryCollection{geom.GeometryCollection{}}} {
+		b, _ := wkb.Marshal(g, binary.LittleEndian)
+		if _, err := wkb.UnmarshalShape("empty", b); err == nil {
+			t.Errorf("Expected error for empty %T", g)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+// Package wkt parses and encodes geometries in the OGC Well-Known Text format.
+package wkt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/lambertmata/gortree/geom"
+)
+
+// Parse decodes a WKT geometry: POINT, LINESTRING, POLYGON, MULTIPOINT, MULTILINESTRING, MULTIPOLYGON or
+// GEOMETRYCOLLECTION. Z, M and ZM geometries are accepted and projected on x and y, and an EWKT SRID prefix is
+// ignored. POINT EMPTY is not supported, as points have no empty representation, and geometry collections nest at most
+// 32 deep.
+func Parse(s string) (geom.Geometry, error) {
+
+	// EWKT prefix, as in SRID=4326;POINT (1 2)
+	if prefix, rest, ok := strings.Cut(s, ";"); ok && strings.HasPrefix(strings.ToUpper(strings.TrimSpace(prefix)), "SRID=") {
+		s = rest
+	}
+
+	p := &parser{input: s}
+
+	g, err := p.geometry()
+	if err != nil {
+		return nil, fmt.Errorf("parse wkt: %w", err)
+	}
+
+	if tok := p.next(); tok != "" {
+		return nil, fmt.Errorf("parse wkt: unexpected %q after geometry", tok)
+	}
+
+	return g, nil
+}
+
+// ParseShape decodes a WKT geometry into a shape with the given ID, ready to be inserted into a gortree.RTree. Empty
+// geometries, which have no bounds, are rejected.
+func ParseShape(id, s string) (*geom.Shape, error) {
+	g, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if geom.IsEmpty(g) {
+		return nil, errors.New("parse wkt: empty geometry")
+	}
+	return &geom.Shape{Name: id, Geometry: g}, nil
+}
+
+// maxDepth is the maximum nesting of geometry collections, so that crafted input can't exhaust the stack.
+const maxDepth = 32
+
+// parser is a recursive descent parser over WKT tokens.
+type parser struct {
+	input  string
+	pos    int
+	peeked string
+	depth  int // nesting of the geometry being parsed in collections
+}
+
+// next returns the next token: a word, a number, a parenthesis or a comma. It returns an empty string at the end of
+// input.
+func (p *parser) next() string {
+
+	if p.peeked != "" {
+		tok := p.peeked
+		p.peeked = ""
+		return tok
+	}
+
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+
+	if p.pos >= len(p.input) {
+		return ""
+	}
+
+	start := p.pos
+	if strings.ContainsRune("(),", rune(p.input[p.pos])) {
+		p.pos++
+		return p.input[start:p.pos]
+	}
+
+	for p.pos < len(p.input) && !unicode.IsSpace(rune(p.input[p.pos])) && !strings.ContainsRune("(),", rune(p.input[p.pos])) {
+		p.pos++
+	}
+
+	return p.input[start:p.pos]
+}
+
+// peek returns the next token without consuming it.
+func (p *parser) peek() string {
+	if p.peeked == "" {
+		p.peeked = p.next()
+	}
+	return p.peeked
+}
+
+// expect consumes the next token, failing if it isn't tok.
+func (p *parser) expect(tok string) error {
+	if got := p.next(); got != tok {
+		if got == "" {
+			return fmt.Errorf("expected %q, got end of input", tok)
+		}
+		return fmt.Errorf("expected %q, got %q", tok, got)
+	}
+	return nil
+}
+
+// empty consumes the EMPTY keyword if present.
+func (p *parser) empty() bool {
+	if strings.EqualFold(p.peek(), "EMPTY") {
+		p.next()
+		return true
+	}
+	return false
+}
+
+// list parses a parenthesized, comma separated list, calling item for each element.
+func (p *parser) list(item func() error) error {
+
+	if err := p.expect("("); err != nil {
+		return err
+	}
+
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+
+		switch tok := p.next(); tok {
+		case ",":
+		case ")":
+			return nil
+		default:
+			return fmt.Errorf("expected \",\" or \")\", got %q", tok)
+		}
+	}
+}
+
+// geometry parses a tagged geometry.
+func (p *parser) geometry() (geom.Geometry, error) {
+
+	tag := strings.ToUpper(p.next())
+	if tag == "" {
+		return nil, errors.New("empty input")
+	}
+
+	// Dimension qualifiers, also accepted when attached to the tag as in POINTZ
+	switch strings.ToUpper(p.peek()) {
+	case "Z", "M", "ZM":
+		p.next()
+	}
+	for _, suffix := range []string{"ZM", "Z", "M"} {
+		if base, ok := strings.CutSuffix(tag, suffix); ok && isTag(base) {
+			tag = base
+			break
+		}
+	}
+
+	switch tag {
+
+	case "POINT":
+		if p.empty() {
+			return nil, errors.New("POINT EMPTY is not supported")
+		}
+		var pt geom.Point
+		err := p.list(func() (err error) {
+			pt, err = p.point()
+			return err
+		})
+		return pt, err
+
+	case "LINESTRING":
+		return p.lineString()
+
+	case "POLYGON":
+		return p.polygon()
+
+	case "MULTIPOINT":
+		m := geom.MultiPoint{}
+		if p.empty() {
+			return m, nil
+		}
+		err := p.list(func() error {
+			// Both MULTIPOINT (1 2, 3 4) and MULTIPOINT ((1 2), (3 4)) are valid
+			var pt geom.Point
+			var err error
+			if p.peek() == "(" {
+				err = p.list(func() (err error) {
+					pt, err = p.point()
+					return err
+				})
+			} else {
+				pt, err = p.point()
+			}
+			m = append(m, pt)
+			return err
+		})
+		return m, err
+
+	case "MULTILINESTRING":
+		m := geom.MultiLineString{}
+		if p.empty() {
+			return m, nil
+		}
+		err := p.list(func() error {
+			l, err := p.lineString()
+			m = append(m, l)
+			return err
+		})
+		return m, err
+
+	case "MULTIPOLYGON":
+		m := geom.MultiPolygon{}
+		if p.empty() {
+			return m, nil
+		}
+		err := p.list(func() error {
+			poly, err := p.polygon()
+			m = append(m, poly)
+			return err
+		})
+		return m, err
+
+	case "GEOMETRYCOLLECTION":
+		c := geom.GeometryCollection{}
+		if p.empty() {
+			return c, nil
+		}
+		if p.depth == maxDepth {
+			return nil, fmt.Errorf("geometry collections nested deeper than %d", maxDepth)
+		}
+		p.depth++
+		err := p.list(func() error {
+			g, err := p.geometry()
+			c = append(c, g)
+			return err
+		})
+		p.depth--
+		return c, err
+
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", tag)
+	}
+}
+
+// isTag tells whether s is a geometry tag.
+func isTag(s string) bool {
+	switch s {
+	case "POINT", "LINESTRING", "POLYGON", "MULTIPOINT", "MULTILINESTRING", "MULTIPOLYGON", "GEOMETRYCOLLECTION":
+		return true
+	}
+	return false
+}
+
+// point parses the coordinates of a position, keeping x and y.
+func (p *parser) point() (geom.Point, error) {
+
+	var coords []float64
+
+	for {
+		tok := p.peek()
+		if tok == "," || tok == ")" || tok == "" {
+			break
+		}
+		p.next()
+
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return geom.Point{}, fmt.Errorf("invalid coordinate %q", tok)
+		}
+		coords = append(coords, v)
+	}
+
+	if len(coords) < 2 || len(coords) > 4 {
+		return geom.Point{}, fmt.Errorf("position must have 2 to 4 coordinates, got %d", len(coords))
+	}
+
+	return geom.Point{coords[0], coords[1]}, nil
+}
+
+// lineString parses a parenthesized list of positions.
+func (p *parser) lineString() (geom.LineString, error) {
+	l := geom.LineString{}
+	if p.empty() {
+		return l, nil
+	}
+	err := p.list(func() error {
+		pt, err := p.point()
+		l = append(l, pt)
+		return err
+	})
+	return l, err
+}
+
+// polygon parses a parenthesized list of rings.
+func (p *parser) polygon() (geom.Polygon, error) {
+	poly := geom.Polygon{}
+	if p.empty() {
+		return poly, nil
+	}
+	err := p.list(func() error {
+		ring, err := p.lineString()
+		poly = append(poly, ring)
+		return err
+	})
+	return poly, err
+}
+
+// Marshal encodes the geometry as WKT.
+func Marshal(g geom.Geometry) (string, error) {
+	var b strings.Builder
+	if err := write(&b, g); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// write appends the tagged geometry to b.
+func write(b *strings.Builder, g geom.Geometry) error {
+
+	switch g := g.(type) {
+
+	case geom.Point:
+		b.WriteString("POINT (")
+		writePoint(b, g)
+		b.WriteString(")")
+
+	case geom.LineString:
+		b.WriteString("LINESTRING ")
+		writeLineString(b, g)
+
+	case geom.Polygon:
+		b.WriteString("POLYGON ")
+		writePolygon(b, g)
+
+	case geom.MultiPoint:
+		b.WriteString("MULTIPOINT ")
+		writeList(b, len(g), func(i int) {
+			b.WriteString("(")
+			writePoint(b, g[i])
+			b.WriteString(")")
+		})
+
+	case geom.MultiLineString:
+		b.WriteString("MULTILINESTRING ")
+		writeList(b, len(g), func(i int) {
+			writeLineString(b, g[i])
+		})
+
+	case geom.MultiPolygon:
+		b.WriteString("MULTIPOLYGON ")
+		writeList(b, len(g), func(i int) {
+			writePolygon(b, g[i])
+		})
+
+	case geom.GeometryCollection:
+		b.WriteString("GEOMETRYCOLLECTION ")
+		var err error
+		writeList(b, len(g), func(i int) {
+			if err == nil {
+				err = write(b, g[i])
+			}
+		})
+		return err
+
+	default:
+		return fmt.Errorf("unsupported geometry %T", g)
+	}
+
+	return nil
+}
+
+// writeList writes count items in parentheses separated by commas, or EMPTY.
+func writeList(b *strings.Builder, count int, item func(i int)) {
+
+	if count == 0 {
+		b.WriteString("EMPTY")
+		return
+	}
+
+	b.WriteString("(")
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		item(i)
+	}
+	b.WriteString(")")
+}
+
+func writePoint(b *strings.Builder, p geom.Point) {
+	b.WriteString(strconv.FormatFloat(p[0], 'f', -1, 64))
+	b.WriteString(" ")
+	b.WriteString(strconv.FormatFloat(p[1], 'f', -1, 64))
+}
+
+func writeLineString(b *strings.Builder, l geom.LineString) {
+	writeList(b, len(l), func(i int) {
+		writePoint(b, l[i])
+	})
+}
+
+func writePolygon(b *strings.Builder, p geom.Polygon) {
+	writeList(b, len(p), func(i int) {
+		writeLineString(b, p[i])
+	})
+}
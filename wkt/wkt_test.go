@@ -0,0 +1,161 @@
+package wkt_test
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lambertmata/gortree"
+	"github.com/lambertmata/gortree/geom"
+	"github.com/lambertmata/gortree/wkt"
+)
+
+func TestParse(t *testing.T) {
+
+	testCases := []struct {
+		Name     string
+		Input    string
+		Expected geom.Geometry
+	}{
+		{"Point", "POINT (1 2)", geom.Point{1, 2}},
+		{"Point Z", "POINT Z (1 2 3)", geom.Point{1, 2}},
+		{"Point ZM attached", "pointzm(1 2 3 4)", geom.Point{1, 2}},
+		{"SRID", "SRID=4326;POINT(-0.5 1e2)", geom.Point{-0.5, 100}},
+		{"LineString", "LINESTRING (0 0, 10 5)", geom.LineString{{0, 0}, {10, 5}}},
+		{"Empty LineString", "LINESTRING EMPTY", geom.LineString{}},
+		{"Polygon", "POLYGON ((0 0, 10 0, 10 10, 0 0), (1 1, 2 1, 2 2, 1 1))",
+			geom.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}, {{1, 1}, {2, 1}, {2, 2}, {1, 1}}}},
+		{"MultiPoint", "MULTIPOINT (1 2, 3 4)", geom.MultiPoint{{1, 2}, {3, 4}}},
+		{"MultiPoint nested", "MULTIPOINT ((1 2), (3 4))", geom.MultiPoint{{1, 2}, {3, 4}}},
+		{"MultiLineString", "MULTILINESTRING ((0 0, 1 1), (2 2, 3 3))", geom.MultiLineString{{{0, 0}, {1, 1}}, {{2, 2}, {3, 3}}}},
+		{"MultiPolygon", "MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)), EMPTY)", geom.MultiPolygon{{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}, {}}},
+		{"GeometryCollection", "GEOMETRYCOLLECTION (POINT (1 2), LINESTRING (0 0, 1 1))",
+			geom.GeometryCollection{geom.Point{1, 2}, geom.LineString{{0, 0}, {1, 1}}}},
+		{"Empty GeometryCollection", "GEOMETRYCOLLECTION EMPTY", geom.GeometryCollection{}},
+	}
+
+	for _, testCase := range testCases {
+		got, err := wkt.Parse(testCase.Input)
+		if err != nil {
+			t.Errorf("Parse %s: %v", testCase.Name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, testCase.Expected) {
+			t.Errorf("Expected %v for %s, got %v", testCase.Expected, testCase.Name, got)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+
+	testCases := []string{
+		"",
+		"CIRCLE (0 0)",
+		"POINT EMPTY",
+		"POINT (1)",
+		"POINT (1 2 3 4 5)",
+		"POINT (1 a)",
+		"POINT (1 2",
+		"LINESTRING (0 0, 1 1))",
+		"POLYGON (0 0, 1 1)",
+		"MULTIPOINT (1 2; 3 4)",
+	}
+
+	for _, input := range testCases {
+		if _, err := wkt.Parse(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestParse_Depth(t *testing.T) {
+
+	// depth geometry collections nested in each other around a point
+	nested := func(depth int) string {
+		return strings.Repeat("GEOMETRYCOLLECTION (", depth) + "POINT (1 2)" + strings.Repeat(")", depth)
+	}
+
+	if _, err := wkt.Parse(nested(32)); err != nil {
+		t.Errorf("Expected 32 nested collections to parse, got %v", err)
+	}
+
+	for _, depth := range []int{33, 1_000_000} {
+		if _, err := wkt.Parse(nested(depth)); err == nil {
+			t.Errorf("Expected error for %d nested collections", depth)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+
+	testCases := []string{
+		"POINT (1.5 -2)",
+		"LINESTRING (0 0, 10 5)",
+		"POLYGON ((0 0, 10 0, 10 10, 0 0), (1 1, 2 1, 2 2, 1 1))",
+		"MULTIPOINT ((1 2), (3 4))",
+		"MULTILINESTRING ((0 0, 1 1), EMPTY)",
+		"MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)))",
+		"GEOMETRYCOLLECTION (POINT (1 2), GEOMETRYCOLLECTION EMPTY)",
+	}
+
+	for _, input := range testCases {
+		g, err := wkt.Parse(input)
+		if err != nil {
+			t.Fatalf("Parse %q: %v", input, err)
+		}
+		got, err := wkt.Marshal(g)
+		if err != nil {
+			t.Fatalf("Marshal %q: %v", input, err)
+		}
+		if got != input {
+			t.Errorf("Expected %q, got %q", input, got)
+		}
+	}
+}
+
+func TestParseShape_Empty(t *testing.T) {
+
+	for _, input := range []string{"LINESTRING EMPTY", "MULTIPOINT EMPTY", "GEOMETRYCOLLECTION (POLYGON EMPTY)"} {
+		if _, err := wkt.ParseShape("empty", input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestParseShape_Query(t *testing.T) {
+
+	rt := gortree.NewRTree()
+
+	for _, item := range []struct{ ID, WKT string }{
+		{"inside", "POINT (1 1)"},
+		{"corner", "POINT (8 8)"},
+		{"road", "LINESTRING (-5 2, 3 2)"},
+		{"far", "POLYGON ((50 50, 60 50, 60 60, 50 50))"},
+	} {
+		shape, err := wkt.ParseShape(item.ID, item.WKT)
+		if err != nil {
+			t.Fatalf("ParseShape %s: %v", item.ID, err)
+		}
+		rt.Insert(shape)
+	}
+
+	region, err := wkt.Parse("POLYGON ((0 0, 10 0, 0 10, 0 0))")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if got := len(rt.Query(region.Bounds())); got != 3 {
+		t.Errorf("Expected 3 candidates in the region bounds, got %d", got)
+	}
+
+	var got []string
+	for _, item := range geom.Intersecting(rt, region) {
+		got = append(got, item.ID())
+	}
+	slices.Sort(got)
+
+	if !slices.Equal(got, []string{"inside", "road"}) {
+		t.Errorf("Expected inside and road, got %v", got)
+	}
+}